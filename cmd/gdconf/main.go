@@ -0,0 +1,75 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+// gdconf prints the reference config of the framework and encrypts config
+// values for ENC(...), e.g.
+//
+//	gdconf sample > conf/conf.ini
+//
+//	export GD_CONFIG_KEY=$(gdconf genkey)
+//	gdconf encrypt 'db password'
+//	echo -n 'db password' | gdconf encrypt
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Xxianglei/gd/config"
+)
+
+const usage = `usage:
+  gdconf sample           print a reference config of the framework keys
+  gdconf genkey           print a new key for GD_CONFIG_KEY
+  gdconf encrypt [value]  print ENC(...) of value or stdin, with the key of
+                          GD_CONFIG_KEY or GD_CONFIG_KEY_FILE`
+
+func main() {
+	if len(os.Args) < 2 {
+		exit(usage)
+	}
+
+	switch os.Args[1] {
+	case "sample":
+		if err := config.WriteSample(os.Stdout); err != nil {
+			exit("write sample fail,%v", err)
+		}
+	case "genkey":
+		key, err := config.GenerateKey()
+		if err != nil {
+			exit("generate key fail,%v", err)
+		}
+		fmt.Println(key)
+	case "encrypt":
+		key, err := config.SecretKey()
+		if err != nil {
+			exit("read key fail,%v", err)
+		}
+		var value string
+		if len(os.Args) > 2 {
+			value = os.Args[2]
+		} else {
+			b, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				exit("read stdin fail,%v", err)
+			}
+			value = strings.TrimRight(string(b), "\r\n")
+		}
+		enc, err := config.Encrypt(key, value)
+		if err != nil {
+			exit("encrypt fail,%v", err)
+		}
+		fmt.Println(enc)
+	default:
+		exit(usage)
+	}
+}
+
+func exit(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	os.Exit(1)
+}
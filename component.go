@@ -0,0 +1,251 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultStopTimeout = 30 * time.Second
+
+// Component is a part of the process managed by the Engine. Components are
+// initialized and started in order of their dependencies and stopped in
+// reverse order.
+type Component interface {
+	// Name identifies the component, it must be unique in an Engine
+	Name() string
+	// Init is called for every component before any of them starts
+	Init() error
+	Start() error
+	// Health returns nil if the component works
+	Health() error
+	Stop() error
+}
+
+// Supervised is implemented by components that serve in background. Run
+// returns and stops all components when one of them sends an error.
+type Supervised interface {
+	// Err is closed without a value if the component exits normally
+	Err() <-chan error
+}
+
+type ComponentOption func(*componentEntry)
+
+// WithDepends makes the component start after the named components.
+func WithDepends(names ...string) ComponentOption {
+	return func(c *componentEntry) {
+		c.depends = append(c.depends, names...)
+	}
+}
+
+// WithStopTimeout bounds how long Stop may take, default DefaultStopTimeout.
+func WithStopTimeout(timeout time.Duration) ComponentOption {
+	return func(c *componentEntry) {
+		c.stopTimeout = timeout
+	}
+}
+
+// WithName overrides Component.Name, e.g. to add two of the same kind.
+func WithName(name string) ComponentOption {
+	return func(c *componentEntry) {
+		c.name = name
+	}
+}
+
+type componentEntry struct {
+	Component
+	name        string
+	depends     []string
+	stopTimeout time.Duration
+	// started is 1 while running, it is read by the probes at any time
+	started int32
+}
+
+func (c *componentEntry) isStarted() bool {
+	return atomic.LoadInt32(&c.started) == 1
+}
+
+func (c *componentEntry) setStarted(started bool) {
+	var v int32
+	if started {
+		v = 1
+	}
+	atomic.StoreInt32(&c.started, v)
+}
+
+// AddComponent adds a component to the Engine. It must be called before Run.
+// Components added by the application start before the built-in servers.
+func (e *Engine) AddComponent(c Component, opts ...ComponentOption) {
+	entry := &componentEntry{
+		Component:   c,
+		name:        c.Name(),
+		stopTimeout: DefaultStopTimeout,
+	}
+	for _, opt := range opts {
+		opt(entry)
+	}
+	e.components = append(e.components, entry)
+}
+
+// Health returns the health of every component by name, nil means healthy.
+func (e *Engine) Health() map[string]error {
+	ret := make(map[string]error, len(e.components))
+	for _, c := range e.components {
+		if !c.isStarted() {
+			ret[c.name] = fmt.Errorf("component %s not started", c.name)
+			continue
+		}
+		ret[c.name] = c.Health()
+	}
+	return ret
+}
+
+// startComponents sorts, initializes and starts all components. If one of
+// them fails the started ones are stopped again.
+func (e *Engine) startComponents() error {
+	sorted, err := sortComponents(e.components)
+	if err != nil {
+		return err
+	}
+	e.components = sorted
+	if e.fatal == nil {
+		e.fatal = make(chan error, 1)
+	}
+
+	for _, c := range e.components {
+		if err := c.Init(); err != nil {
+			return fmt.Errorf("component %s init fail,%v", c.name, err)
+		}
+	}
+
+	for _, c := range e.components {
+		e.info("component %s start...", c.name)
+		if err := c.Start(); err != nil {
+			e.stopComponents()
+			return fmt.Errorf("component %s start fail,%v", c.name, err)
+		}
+		c.setStarted(true)
+
+		if s, ok := c.Component.(Supervised); ok {
+			e.supervise(c.name, s.Err())
+		}
+	}
+	return nil
+}
+
+// supervise reports the first error of all components to e.fatal.
+func (e *Engine) supervise(name string, ch <-chan error) {
+	if ch == nil {
+		return
+	}
+	go func() {
+		if err, ok := <-ch; ok && err != nil {
+			select {
+			case e.fatal <- fmt.Errorf("component %s fail,%v", name, err):
+			default:
+			}
+		}
+	}()
+}
+
+// stopComponents stops the started components in reverse order.
+func (e *Engine) stopComponents() {
+	for i := len(e.components) - 1; i >= 0; i-- {
+		c := e.components[i]
+		if !c.isStarted() {
+			continue
+		}
+		c.setStarted(false)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- c.Stop()
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				e.error("component %s stop fail, error = %s", c.name, err.Error())
+			} else {
+				e.info("component %s stop ok", c.name)
+			}
+		case <-time.After(c.stopTimeout):
+			e.error("component %s stop timeout after %v", c.name, c.stopTimeout)
+		}
+	}
+}
+
+// status is shown by the status command of the health server.
+func (e *Engine) status() string {
+	return fmt.Sprintf("%s\nresource:%s", e.healthStatus(), e.resource)
+}
+
+func (e *Engine) healthStatus() string {
+	var ret []string
+	for name, err := range e.Health() {
+		if err != nil {
+			ret = append(ret, fmt.Sprintf("%s:%v", name, err))
+		} else {
+			ret = append(ret, fmt.Sprintf("%s:ok", name))
+		}
+	}
+	sort.Strings(ret)
+	return strings.Join(ret, ",")
+}
+
+// sortComponents orders components so that each one comes after its
+// dependencies, otherwise the order of AddComponent is kept.
+func sortComponents(entries []*componentEntry) ([]*componentEntry, error) {
+	byName := make(map[string]*componentEntry, len(entries))
+	for _, c := range entries {
+		if _, ok := byName[c.name]; ok {
+			return nil, fmt.Errorf("component %s added twice", c.name)
+		}
+		byName[c.name] = c
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(entries))
+	sorted := make([]*componentEntry, 0, len(entries))
+
+	var visit func(c *componentEntry) error
+	visit = func(c *componentEntry) error {
+		switch state[c.name] {
+		case visiting:
+			return fmt.Errorf("component %s has cyclic dependency", c.name)
+		case visited:
+			return nil
+		}
+
+		state[c.name] = visiting
+		for _, name := range c.depends {
+			dep, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("component %s depends on unknown component %s", c.name, name)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[c.name] = visited
+		sorted = append(sorted, c)
+		return nil
+	}
+
+	for _, c := range entries {
+		if err := visit(c); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,343 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Xxianglei/gd/runtime/health"
+	"github.com/Xxianglei/gd/runtime/helper"
+	"github.com/Xxianglei/gd/runtime/memwatch"
+	"net"
+	"net/http"
+	"time"
+)
+
+// built-in component names
+const (
+	ComponentHttp     = "http"
+	ComponentGrpc     = "grpc"
+	ComponentHealth   = "health"
+	ComponentRpc      = "rpc"
+	ComponentRegister = "register"
+	ComponentMemwatch = "memwatch"
+	ComponentProbe    = "probe"
+)
+
+type httpComponent struct {
+	e    *Engine
+	port int
+}
+
+func (c *httpComponent) Name() string  { return ComponentHttp }
+func (c *httpComponent) Init() error   { return nil }
+func (c *httpComponent) Health() error { return nil }
+
+func (c *httpComponent) Start() error {
+	c.e.info("http server try listen port:%d", c.port)
+
+	c.e.HttpServer.HttpServerRunHost = fmt.Sprintf(":%d", c.port)
+	l, err := c.e.listen(ComponentHttp, c.e.HttpServer.HttpServerRunHost)
+	if err != nil {
+		return err
+	}
+	c.e.HttpServer.Listener = l
+	return c.e.HttpServer.Run()
+}
+
+func (c *httpComponent) Check(ctx context.Context) error {
+	return checkListener(ctx, c.e.HttpServer.Listener)
+}
+
+func (c *httpComponent) Err() <-chan error {
+	return c.e.HttpServer.Err()
+}
+
+func (c *httpComponent) Stop() error {
+	c.e.HttpServer.Stop()
+	return nil
+}
+
+type grpcComponent struct {
+	e    *Engine
+	port int
+}
+
+func (c *grpcComponent) Name() string  { return ComponentGrpc }
+func (c *grpcComponent) Init() error   { return nil }
+func (c *grpcComponent) Health() error { return nil }
+
+func (c *grpcComponent) Start() error {
+	c.e.info("grpc server try listen port:%d", c.port)
+
+	c.e.GrpcServer.GrpcRunPort = c.port
+	c.e.GrpcServer.ServiceName = c.e.Config("Server", "serverName").String()
+	l, err := c.e.listen(ComponentGrpc, fmt.Sprintf(":%d", c.port))
+	if err != nil {
+		return err
+	}
+	c.e.GrpcServer.Listener = l
+	return c.e.GrpcServer.Run()
+}
+
+func (c *grpcComponent) Check(ctx context.Context) error {
+	return checkListener(ctx, c.e.GrpcServer.Listener)
+}
+
+func (c *grpcComponent) Err() <-chan error {
+	return c.e.GrpcServer.Err()
+}
+
+func (c *grpcComponent) Stop() error {
+	c.e.GrpcServer.Stop()
+	return nil
+}
+
+type healthComponent struct {
+	e        *Engine
+	port     int
+	helper   *helper.Helper
+	listener net.Listener
+}
+
+func (c *healthComponent) Name() string  { return ComponentHealth }
+func (c *healthComponent) Init() error   { return nil }
+func (c *healthComponent) Health() error { return nil }
+
+func (c *healthComponent) Start() error {
+	c.e.info("health server try listen port:%d", c.port)
+
+	host := fmt.Sprintf(":%d", c.port)
+	l, err := c.e.listen(ComponentHealth, host)
+	if err != nil {
+		return err
+	}
+	c.listener = l
+	c.helper = &helper.Helper{
+		Host:     host,
+		Listener: l,
+		Stater:   c.e.status,
+	}
+	return c.helper.Start()
+}
+
+func (c *healthComponent) Check(ctx context.Context) error {
+	return checkListener(ctx, c.listener)
+}
+
+func (c *healthComponent) Stop() error {
+	c.helper.Close()
+	return nil
+}
+
+type rpcComponent struct {
+	e        *Engine
+	port     int
+	listener net.Listener
+}
+
+func (c *rpcComponent) Name() string  { return ComponentRpc }
+func (c *rpcComponent) Init() error   { return nil }
+func (c *rpcComponent) Health() error { return nil }
+
+func (c *rpcComponent) Start() error {
+	c.e.info("rpc server try listen port:%d", c.port)
+	l, err := c.e.listen(ComponentRpc, fmt.Sprintf(":%d", c.port))
+	if err != nil {
+		return err
+	}
+	c.listener = l
+	c.e.RpcServer.SetListener(l)
+	return c.e.RpcServer.Start(c.port)
+}
+
+func (c *rpcComponent) Check(ctx context.Context) error {
+	return checkListener(ctx, c.listener)
+}
+
+func (c *rpcComponent) Err() <-chan error {
+	return c.e.RpcServer.Err()
+}
+
+func (c *rpcComponent) Stop() error {
+	c.e.RpcServer.Stop()
+	return nil
+}
+
+type memwatchComponent struct {
+	e        *Engine
+	watchdog *memwatch.Watchdog
+}
+
+func (c *memwatchComponent) Name() string { return ComponentMemwatch }
+func (c *memwatchComponent) Init() error  { return nil }
+
+func (c *memwatchComponent) Health() error {
+	if memwatch.Overloaded() {
+		return errors.New("memory overloaded")
+	}
+	return nil
+}
+
+func (c *memwatchComponent) Start() error {
+	return c.watchdog.Start()
+}
+
+func (c *memwatchComponent) Stop() error {
+	c.watchdog.Stop()
+	return nil
+}
+
+// probeComponent serves /healthz and /readyz over http, and the levels of
+// tags and packages of the logs to the holder of [Process] adminToken. It
+// starts first so that readiness can be probed while the other components
+// start.
+type probeComponent struct {
+	e        *Engine
+	port     int
+	server   *http.Server
+	listener net.Listener
+	errChan  chan error
+}
+
+func (c *probeComponent) Name() string { return ComponentProbe }
+func (c *probeComponent) Init() error  { return nil }
+
+func (c *probeComponent) Start() error {
+	c.e.info("probe server try listen port:%d", c.port)
+	l, err := c.e.listen(ComponentProbe, fmt.Sprintf(":%d", c.port))
+	if err != nil {
+		return err
+	}
+	c.listener = l
+	mux := http.NewServeMux()
+	mux.Handle("/", health.Handler(c.e.readiness, c.e.probeTimeout()))
+	if token := c.e.Config("Process", "adminToken").String(); token != "" {
+		mux.Handle(helper.LevelPath, helper.LevelHandler(token))
+	}
+	c.server = &http.Server{Handler: mux}
+
+	c.errChan = make(chan error, 1)
+	go func() {
+		defer close(c.errChan)
+		if err := c.server.Serve(l); err != nil && err != http.ErrServerClosed {
+			c.e.error("probe server serve fail,%v", err)
+			c.errChan <- err
+		}
+	}()
+	return nil
+}
+
+func (c *probeComponent) Health() error { return nil }
+
+func (c *probeComponent) Check(ctx context.Context) error {
+	return checkListener(ctx, c.listener)
+}
+
+func (c *probeComponent) Err() <-chan error {
+	return c.errChan
+}
+
+func (c *probeComponent) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return c.server.Shutdown(ctx)
+}
+
+type registerComponent struct {
+	e     *Engine
+	ports []registerPort
+}
+
+func (c *registerComponent) Name() string  { return ComponentRegister }
+func (c *registerComponent) Init() error   { return nil }
+func (c *registerComponent) Health() error { return nil }
+
+func (c *registerComponent) Start() error {
+	return c.e.startRegister(c.ports)
+}
+
+// Check checks the registers that are a health.Checker, e.g. zookeeper
+func (c *registerComponent) Check(ctx context.Context) error {
+	for _, r := range c.e.registers {
+		if checker, ok := r.(health.Checker); ok {
+			if err := checker.Check(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *registerComponent) Stop() error {
+	c.e.stopRegister()
+	return nil
+}
+
+// addServerComponents adds the probe server and the memory watchdog if they
+// are enabled, the servers whose port or listener is set, followed by the
+// register that depends on them.
+func (e *Engine) addServerComponents(httpPort, grpcPort, healthPort, rpcPort, probePort int) error {
+	if e.serves(ComponentProbe, probePort) {
+		// before the components of application to probe them while they start
+		e.AddComponent(&probeComponent{e: e, port: probePort})
+		last := len(e.components) - 1
+		e.components = append([]*componentEntry{e.components[last]}, e.components[:last]...)
+	}
+
+	soft, hard, err := e.memwatchLimits()
+	if err != nil {
+		return err
+	}
+	if soft > 0 || hard > 0 {
+		e.AddComponent(&memwatchComponent{
+			e: e,
+			watchdog: &memwatch.Watchdog{
+				Soft:         soft,
+				Hard:         hard,
+				GCInterval:   time.Duration(e.Config("Process", "gcInterval").MustInt(0)) * time.Second,
+				DumpInterval: time.Duration(e.Config("Process", "heapDumpInterval").MustInt(0)) * time.Second,
+			},
+		})
+	}
+
+	var servers []string
+	if e.serves(ComponentHttp, httpPort) {
+		e.AddComponent(&httpComponent{e: e, port: httpPort})
+		servers = append(servers, ComponentHttp)
+	}
+	if e.serves(ComponentGrpc, grpcPort) {
+		e.AddComponent(&grpcComponent{e: e, port: grpcPort})
+		servers = append(servers, ComponentGrpc)
+	}
+	if e.serves(ComponentHealth, healthPort) {
+		e.AddComponent(&healthComponent{e: e, port: healthPort})
+	}
+	if e.serves(ComponentRpc, rpcPort) {
+		e.AddComponent(&rpcComponent{e: e, port: rpcPort})
+		servers = append(servers, ComponentRpc)
+	}
+
+	drainTime := e.Config("Register", "drainTime").MustInt(defaultDrainTime)
+	e.AddComponent(&registerComponent{
+		e: e,
+		ports: []registerPort{
+			{name: "http", port: httpPort},
+			{name: "rpc", port: rpcPort},
+			{name: "grpc", port: grpcPort},
+		},
+	}, WithDepends(servers...), WithStopTimeout(DefaultStopTimeout+time.Duration(drainTime)*time.Second))
+	return nil
+}
+
+func (e *Engine) serves(name string, port int) bool {
+	if port > 0 {
+		return true
+	}
+	_, ok := e.listeners[name]
+	return ok
+}
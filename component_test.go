@@ -0,0 +1,165 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logLock guards the logs of fakeComponent, a blocked Stop writes after the
+// test reads.
+var logLock sync.Mutex
+
+type fakeComponent struct {
+	name     string
+	log      *[]string
+	startErr error
+	// Stop waits for unblock if set, and closes stopped when it returns
+	unblock chan struct{}
+	stopped chan struct{}
+}
+
+func (c *fakeComponent) Name() string  { return c.name }
+func (c *fakeComponent) Init() error   { return nil }
+func (c *fakeComponent) Health() error { return nil }
+
+func (c *fakeComponent) Start() error {
+	c.write("start " + c.name)
+	return c.startErr
+}
+
+func (c *fakeComponent) Stop() error {
+	if c.unblock != nil {
+		<-c.unblock
+	}
+	c.write("stop " + c.name)
+	if c.stopped != nil {
+		close(c.stopped)
+	}
+	return nil
+}
+
+func (c *fakeComponent) write(s string) {
+	logLock.Lock()
+	defer logLock.Unlock()
+	*c.log = append(*c.log, s)
+}
+
+func TestComponentOrder(t *testing.T) {
+	var log []string
+	e := &Engine{}
+	e.AddComponent(&fakeComponent{name: "server", log: &log}, WithDepends("db", "cache"))
+	e.AddComponent(&fakeComponent{name: "cache", log: &log}, WithDepends("db"))
+	e.AddComponent(&fakeComponent{name: "db", log: &log})
+
+	if err := e.startComponents(); err != nil {
+		t.Fatal(err)
+	}
+	for name, err := range e.Health() {
+		if err != nil {
+			t.Errorf("%s health = %v", name, err)
+		}
+	}
+	e.stopComponents()
+
+	want := []string{"start db", "start cache", "start server", "stop server", "stop cache", "stop db"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("log = %v, want %v", log, want)
+	}
+}
+
+func TestComponentStartFail(t *testing.T) {
+	var log []string
+	e := &Engine{}
+	e.AddComponent(&fakeComponent{name: "db", log: &log})
+	e.AddComponent(&fakeComponent{name: "server", log: &log, startErr: errors.New("listen fail")})
+	e.AddComponent(&fakeComponent{name: "register", log: &log})
+
+	if err := e.startComponents(); err == nil {
+		t.Fatal("start should fail")
+	}
+
+	want := []string{"start db", "start server", "stop db"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("log = %v, want %v", log, want)
+	}
+}
+
+func TestComponentStopTimeout(t *testing.T) {
+	var log []string
+	e := &Engine{}
+	e.AddComponent(&fakeComponent{name: "db", log: &log})
+	slow := &fakeComponent{name: "slow", log: &log, unblock: make(chan struct{}), stopped: make(chan struct{})}
+	e.AddComponent(slow, WithStopTimeout(10*time.Millisecond))
+
+	if err := e.startComponents(); err != nil {
+		t.Fatal(err)
+	}
+	e.stopComponents()
+
+	logLock.Lock()
+	last := log[len(log)-1]
+	logLock.Unlock()
+	if last != "stop db" {
+		t.Errorf("log = %v, db should stop without waiting for slow", log)
+	}
+
+	close(slow.unblock)
+	<-slow.stopped
+}
+
+func TestComponentDependsError(t *testing.T) {
+	var log []string
+	e := &Engine{}
+	e.AddComponent(&fakeComponent{name: "a", log: &log}, WithDepends("b"))
+	e.AddComponent(&fakeComponent{name: "b", log: &log}, WithDepends("a"))
+	if err := e.startComponents(); err == nil {
+		t.Error("cyclic dependency should fail")
+	}
+
+	e = &Engine{}
+	e.AddComponent(&fakeComponent{name: "a", log: &log}, WithDepends("unknown"))
+	if err := e.startComponents(); err == nil {
+		t.Error("unknown dependency should fail")
+	}
+
+	if len(log) != 0 {
+		t.Errorf("log = %v, nothing should start", log)
+	}
+}
+
+type supervisedComponent struct {
+	fakeComponent
+	err chan error
+}
+
+func (c *supervisedComponent) Err() <-chan error { return c.err }
+
+func TestComponentSupervise(t *testing.T) {
+	var log []string
+	e := &Engine{}
+	c := &supervisedComponent{fakeComponent: fakeComponent{name: "server", log: &log}, err: make(chan error, 1)}
+	e.AddComponent(c)
+
+	if err := e.startComponents(); err != nil {
+		t.Fatal(err)
+	}
+	defer e.stopComponents()
+
+	c.err <- errors.New("serve fail")
+	select {
+	case err := <-e.fatal:
+		if err == nil {
+			t.Error("fatal error is nil")
+		}
+	case <-time.After(time.Second):
+		t.Error("serve error not reported")
+	}
+}
@@ -7,7 +7,7 @@ package gd
 
 import (
 	"github.com/Xxianglei/gd/config"
-	"gopkg.in/ini.v1"
+	"io"
 )
 
 // set conf path
@@ -15,12 +15,59 @@ func SetConfPath(path string) {
 	config.SetConfPath(path)
 }
 
-// get config
-func Config(name, key string) *ini.Key {
-	return config.Config().Section(name).Key(key)
+// get config, the value of a flag --name.key=value or an env GD_NAME_KEY
+// overrides the file
+func Config(name, key string) *config.Key {
+	return config.Config().Key(name, key)
+}
+
+// reload config file, see config.Reload
+func ReloadConfig() error {
+	return config.Reload()
+}
+
+// subscribe config changes, see config.Subscribe
+func SubscribeConfig(name, key string, fn config.SubscribeFunc) (cancel func()) {
+	return config.Subscribe(name, key, fn)
+}
+
+// bind a config section to a struct, see config.Bind
+func BindConfig(name string, v interface{}) error {
+	return config.Bind(name, v)
+}
+
+// declare the keys of a section read by the application, the fields of v
+// are declared as bound by BindConfig, see config.DeclareStruct
+func DeclareConfig(name string, v interface{}) error {
+	return config.DeclareStruct(name, v)
+}
+
+// write a reference config of every declared key, see config.WriteSample
+func WriteConfigSample(w io.Writer) error {
+	return config.WriteSample(w)
 }
 
 // set config
 func SetConfig(name, key, value string) {
-	config.Config().Section(name).Key(key).SetValue(value)
+	config.SetValue(name, key, value)
+}
+
+// set the default of a config key, see config.SetDefault
+func SetConfigDefault(name, key, value string) {
+	config.SetDefault(name, key, value)
+}
+
+// merge the sections under root of zk over the config file and reload on
+// changes, cache is the file read while zk is down, see config.ZkSource
+func SetConfigZk(hosts []string, root, cache string) error {
+	s, err := config.ConnectZk(hosts, root, cache)
+	if err != nil {
+		return err
+	}
+	return config.SetRemote(s)
+}
+
+// get every config value and its source: default, file, remote, env, flag or code
+func ConfigValues() []config.Value {
+	return config.Config().Values()
 }
@@ -0,0 +1,296 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Xxianglei/gd/utls"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Size is a number of bytes, it is bound from a plain number or a size such
+// as "512m" or "2g", see utls.ParseMemorySize.
+type Size uint64
+
+var (
+	sizeType     = reflect.TypeOf(Size(0))
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// units of a time.Duration bound from a plain number, see the unit tag
+var units = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// KeyError is the error of one key of a section.
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e KeyError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Key, e.Err)
+}
+
+// BindError lists every key of a section that cannot be bound.
+type BindError struct {
+	Section string
+	Keys    []KeyError
+}
+
+func (e *BindError) Error() string {
+	s := make([]string, len(e.Keys))
+	for i, k := range e.Keys {
+		s[i] = k.Error()
+	}
+	return fmt.Sprintf("config [%s] bind fail, %s", e.Section, strings.Join(s, "; "))
+}
+
+// Bind sets the fields of the struct v points to from section of Config,
+// see Conf.Bind.
+func Bind(section string, v interface{}) error {
+	return Config().Bind(section, v)
+}
+
+// Bind sets the exported fields of the struct v points to from section. The
+// tags of a field are:
+//
+//	config:"httpPort"        key name, default the field name starting in lower case, "-" skips the field
+//	default:"10240"          value if the key is not set
+//	required:"true"          the key must be set
+//	min:"1" max:"65535"      bounds of a number, Size or time.Duration
+//	enum:"DEBUG,INFO"        allowed values
+//	unit:"s"                 unit of a time.Duration set by a plain number, default "ms"
+//
+// Fields may be strings, bools, numbers, Size, time.Duration or []string
+// split by ",". All keys are bound even if one fails, the returned
+// *BindError names every bad key.
+func (c *Conf) Bind(section string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config bind needs a pointer to a struct")
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	bindErr := &BindError{Section: section}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		key := f.Tag.Get("config")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = lowerFirst(f.Name)
+		}
+
+		if err := c.bindField(section, key, f, rv.Field(i)); err != nil {
+			bindErr.Keys = append(bindErr.Keys, KeyError{Key: key, Err: err})
+		}
+	}
+
+	if len(bindErr.Keys) > 0 {
+		return bindErr
+	}
+	return nil
+}
+
+func (c *Conf) bindField(section, key string, f reflect.StructField, v reflect.Value) error {
+	value := c.Key(section, key).String()
+	if value == "" {
+		if f.Tag.Get("required") == "true" {
+			return errors.New("required")
+		}
+		value = f.Tag.Get("default")
+	}
+	if value == "" {
+		v.Set(reflect.Zero(f.Type))
+		return nil
+	}
+
+	shown := c.shown(section, key, value)
+	if enum := f.Tag.Get("enum"); enum != "" && !contains(strings.Split(enum, ","), value) {
+		return fmt.Errorf("%q not one of %s", shown, enum)
+	}
+
+	unit := f.Tag.Get("unit")
+	if err := setShown(v, value, shown, unit); err != nil {
+		return err
+	}
+
+	for _, bound := range []string{"min", "max"} {
+		b := f.Tag.Get(bound)
+		if b == "" {
+			continue
+		}
+		bv := reflect.New(f.Type).Elem()
+		if err := setValue(bv, b, unit); err != nil {
+			return fmt.Errorf("invalid %s tag %q,%v", bound, b, err)
+		}
+		cmp, err := compare(v, bv)
+		if err != nil {
+			return err
+		}
+		if bound == "min" && cmp < 0 {
+			return fmt.Errorf("%s below min %s", shown, b)
+		}
+		if bound == "max" && cmp > 0 {
+			return fmt.Errorf("%s above max %s", shown, b)
+		}
+	}
+	return nil
+}
+
+// setShown is setValue with shown instead of value in the error, see
+// Conf.shown.
+func setShown(v reflect.Value, value, shown, unit string) error {
+	err := setValue(v, value, unit)
+	if err != nil && shown != value {
+		return fmt.Errorf("invalid %s %s", v.Type(), shown)
+	}
+	return err
+}
+
+func setValue(v reflect.Value, s, unit string) error {
+	switch {
+	case v.Type() == sizeType:
+		n, err := parseSize(s)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+		return nil
+	case v.Type() == durationType:
+		d, err := parseDuration(s, unit)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid int %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid uint %q", s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid float %q", s)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var values []string
+		for _, e := range strings.Split(s, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				values = append(values, e)
+			}
+		}
+		v.Set(reflect.ValueOf(values).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func parseSize(s string) (uint64, error) {
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return n, nil
+	}
+	n, err := utls.ParseMemorySize(s)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n, nil
+}
+
+func parseDuration(s, unit string) (time.Duration, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if unit == "" {
+			unit = "ms"
+		}
+		u, ok := units[unit]
+		if !ok {
+			return 0, fmt.Errorf("invalid unit tag %q", unit)
+		}
+		return time.Duration(n) * u, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func compare(a, b reflect.Value) (int, error) {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmpFloat(float64(a.Int()), float64(b.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmpFloat(float64(a.Uint()), float64(b.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return cmpFloat(a.Float(), b.Float()), nil
+	}
+	return 0, fmt.Errorf("min and max not supported by %s", a.Type())
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
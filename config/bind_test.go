@@ -0,0 +1,140 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"gopkg.in/ini.v1"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadConf(t *testing.T, content string) *Conf {
+	f, err := ini.Load([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewConf(f)
+}
+
+type dbConf struct {
+	Host     string        `config:"host" required:"true"`
+	Port     int           `config:"port" default:"3306" min:"1" max:"65535"`
+	MaxOpen  int           `config:"max_open" default:"100"`
+	Timeout  time.Duration `config:"timeout" default:"5s"`
+	Idle     time.Duration `config:"idle" unit:"s"`
+	Buffer   Size          `config:"buffer" max:"1g"`
+	Slaves   []string      `config:"slaves"`
+	Proxy    bool          `config:"is_proxy"`
+	Charset  string        `enum:"utf8,utf8mb4" default:"utf8mb4"`
+	Ignored  string        `config:"-"`
+	internal string
+}
+
+func TestBind(t *testing.T) {
+	c := loadConf(t, `
+[Mysql.mydb]
+host     = 10.0.0.1
+idle     = 60
+buffer   = 512m
+slaves   = 10.0.0.2, 10.0.0.3
+is_proxy = true
+Ignored  = x
+`)
+	var got dbConf
+	if err := c.Bind("Mysql.mydb", &got); err != nil {
+		t.Fatal(err)
+	}
+	want := dbConf{
+		Host:    "10.0.0.1",
+		Port:    3306,
+		MaxOpen: 100,
+		Timeout: 5 * time.Second,
+		Idle:    time.Minute,
+		Buffer:  512 << 20,
+		Slaves:  []string{"10.0.0.2", "10.0.0.3"},
+		Proxy:   true,
+		Charset: "utf8mb4",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bind = %+v, want %+v", got, want)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	c := loadConf(t, `
+[Mysql.mydb]
+port     = 70000
+max_open = many
+timeout  = 5 seconds
+buffer   = 2g
+charset  = latin1
+`)
+	err := c.Bind("Mysql.mydb", &dbConf{})
+	bindErr, ok := err.(*BindError)
+	if !ok {
+		t.Fatalf("err = %v, want *BindError", err)
+	}
+	var keys []string
+	for _, k := range bindErr.Keys {
+		keys = append(keys, k.Key)
+	}
+	want := []string{"host", "port", "max_open", "timeout", "buffer", "charset"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("bad keys = %v, want %v", keys, want)
+	}
+	if !strings.Contains(err.Error(), "port: 70000 above max 65535") {
+		t.Errorf("err = %v", err)
+	}
+
+	if err := c.Bind("Mysql.mydb", dbConf{}); err == nil {
+		t.Error("bind to a non-pointer should fail")
+	}
+}
+
+func TestBindSections(t *testing.T) {
+	c := loadConf(t, `
+[Server]
+serverName = gd
+httpPort   = 10240
+
+[Process]
+maxMemory      = 2g
+upgradeTimeout = 60
+
+[Register]
+zkHost = 10.0.0.1:2181,10.0.0.2:2181
+`)
+	var server ServerConf
+	var log LogConf
+	var process ProcessConf
+	var stat StatisticsConf
+	var register RegisterConf
+	for section, v := range map[string]interface{}{
+		"Server": &server, "Log": &log, "Process": &process, "Statistics": &stat, "Register": &register,
+	} {
+		if err := c.Bind(section, v); err != nil {
+			t.Errorf("%s: %v", section, err)
+		}
+	}
+
+	if server.HttpPort != 10240 || server.ServerName != "gd" {
+		t.Errorf("server = %+v", server)
+	}
+	if log.Level != "DEBUG" || log.LogDir != "log" {
+		t.Errorf("log = %+v", log)
+	}
+	if process.MaxMemory != 2<<30 || process.UpgradeTimeout != time.Minute || process.ProbeTimeout != time.Second {
+		t.Errorf("process = %+v", process)
+	}
+	if stat.StatInterval != 5*time.Second {
+		t.Errorf("statistics = %+v", stat)
+	}
+	if len(register.ZkHost) != 2 || register.DrainTime != 5 || register.Weight != 10 {
+		t.Errorf("register = %+v", register)
+	}
+}
@@ -14,48 +14,174 @@ import (
 var (
 	defaultConfigName = "conf/conf.ini"
 	cache             sync.Map
+	lock              sync.Mutex
+	// reloadLock orders the loads of the remote source and the file, lock is
+	// not held while the remote source is read
+	reloadLock sync.Mutex
+	overrides  = make(map[string]map[string]string)
 )
 
 type Conf struct {
-	ini *ini.File
+	ini     *ini.File
+	layered bool
+	lock    sync.RWMutex
+	sources map[sourceKey]string
+	secrets map[sourceKey]bool
 }
 
-func (c *Conf) Section(name string) *ini.Section {
+// NewConf wraps f, it is not affected by SetValue, Reload and overrides of
+// env and flags. Secret references in f are replaced by their values, a
+// reference that cannot be resolved is logged and kept.
+func NewConf(f *ini.File) *Conf {
+	c, err := newConf(f)
+	if err != nil {
+		dlog.Error("Config resolve secrets occur error:%v", err)
+	}
+	return c
+}
+
+func newConf(f *ini.File) (*Conf, error) {
+	c := &Conf{ini: f}
+	var errs errorList
+	for _, s := range f.Sections() {
+		for _, k := range s.Keys() {
+			errs.add(c.set(s.Name(), k.Name(), k.String(), SourceFile))
+		}
+	}
+	return c, errs.err()
+}
+
+// Load reads a config file or directory into a Conf not shared with Config,
+// see SetConfPath.
+func Load(path string) (*Conf, error) {
+	f, err := loadPath(path, Profile())
+	if err != nil {
+		return nil, err
+	}
+	return newConf(f)
+}
+
+func (c *Conf) Section(name string) *Section {
 	return c.ini.Section(name)
 }
 
+// SetConfPath sets the file read by Config, its extension selects the format:
+// .ini, .yaml, .yml, .json or .toml. The path may be a directory, its files
+// are merged in order of their names. The overlay of the profile, e.g.
+// conf.prod.ini of conf.ini, is merged last, see Profile.
 func SetConfPath(path string) {
 	if path != "" {
 		defaultConfigName = path
 	}
 }
 
+// Path returns the file or directory read by Config.
+func Path() string {
+	return defaultConfigName
+}
+
+// Config returns the config file of SetConfPath with the values of flags,
+// env, SetDefault and SetValue applied, see layer.
 func Config() *Conf {
-	cfg, ok := getFile(defaultConfigName)
+	cfg, ok := getConf(defaultConfigName)
 	if !ok {
-		tmp, err := ini.Load(defaultConfigName)
+		reloadLock.Lock()
+		defer reloadLock.Unlock()
+		if cfg, ok = getConf(defaultConfigName); ok {
+			return cfg
+		}
+
+		rf, err := loadRemote()
+		if err != nil {
+			dlog.Error("Config load remote occur error:%v", err)
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		tmp, err := loadPath(defaultConfigName, currentProfile())
 		if err != nil {
-			dlog.Warn("Config ini load conf/conf.ini occur error:%v", err)
-			return &Conf{ini: ini.Empty()}
+			dlog.Warn("Config load %s occur error:%v", defaultConfigName, err)
+			tmp = ini.Empty()
+		}
+		if rf != nil {
+			merge(tmp, rf)
 		}
-		setFile(defaultConfigName, tmp)
-		cfg = tmp
+		if cfg, err = layer(tmp, rf, nil); err != nil {
+			dlog.Error("Config resolve %s occur error:%v", defaultConfigName, err)
+		}
+		setConf(defaultConfigName, cfg)
+	}
+	return cfg
+}
+
+// SetValue sets a key in memory. The value survives Reload, so values set by
+// code are not overwritten by the file, env or flags.
+func SetValue(section, key, value string) {
+	lock.Lock()
+	setIn(overrides, section, key, value)
+	lock.Unlock()
+
+	if err := Config().set(section, key, value, SourceCode); err != nil {
+		dlog.Error("Config set occur error:%v", err)
+	}
+}
+
+// Reload re-reads the config file and the remote source and notifies subscribers of every key that
+// changed. If the file cannot be loaded the current config is kept.
+func Reload() error {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	rf, err := loadRemote()
+	if err != nil {
+		dlog.Error("Config reload %s occur error, keep previous config:%v", defaultConfigName, err)
+		return err
+	}
+	lock.Lock()
+	tmp, err := loadPath(defaultConfigName, currentProfile())
+	if err == nil && rf != nil {
+		merge(tmp, rf)
+	}
+	if err != nil {
+		lock.Unlock()
+		dlog.Error("Config reload %s occur error, keep previous config:%v", defaultConfigName, err)
+		return err
+	}
+	old, _ := getConf(defaultConfigName)
+	cfg, err := layer(tmp, rf, old)
+	if err != nil {
+		lock.Unlock()
+		dlog.Error("Config reload %s occur error, keep previous config:%v", defaultConfigName, err)
+		return err
 	}
-	return &Conf{ini: cfg}
+	changes := diff(old.ini, cfg.ini)
+	setConf(defaultConfigName, cfg)
+	lock.Unlock()
+
+	dlog.Info("Config reload %s success, %d keys changed", defaultConfigName, len(changes))
+	for _, c := range changes {
+		if old.secret(c.Section, c.Key) || cfg.secret(c.Section, c.Key) {
+			dlog.Info("Config reload [%s] %s: secret changed", c.Section, c.Key)
+			continue
+		}
+		dlog.Info("Config reload [%s] %s: %q -> %q", c.Section, c.Key, c.Old, c.New)
+	}
+
+	notify(changes)
+	return nil
 }
 
-func getFile(name string) (*ini.File, bool) {
-	fo, ok := cache.Load(name)
-	if !ok || fo == nil {
-		return ini.Empty(), false
+func getConf(name string) (*Conf, bool) {
+	co, ok := cache.Load(name)
+	if !ok || co == nil {
+		return &Conf{ini: ini.Empty()}, false
 	}
-	f, ok := fo.(*ini.File)
-	if !ok || f == nil {
-		return ini.Empty(), false
+	c, ok := co.(*Conf)
+	if !ok || c == nil {
+		return &Conf{ini: ini.Empty()}, false
 	}
-	return f, ok
+	return c, ok
 }
 
-func setFile(name string, file *ini.File) {
-	cache.Store(name, file)
+func setConf(name string, c *Conf) {
+	cache.Store(name, c)
 }
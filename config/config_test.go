@@ -0,0 +1,112 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConf(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "conf.ini")
+	writeConf(t, path, "[Log]\nlevel = DEBUG\n[Statistics]\nstatInterval = 5\n")
+	SetConfPath(path)
+
+	if v := Config().Section("Log").Key("level").String(); v != "DEBUG" {
+		t.Fatalf("level = %q, want DEBUG", v)
+	}
+	SetValue("Server", "httpPort", "10240")
+	// lookup of a missing key must not be reported as a change
+	_ = Config().Section("Server").Key("rpcPort").String()
+
+	var changes []Change
+	Subscribe("Log", "level", func(c Change) {
+		changes = append(changes, c)
+	})
+	Subscribe("Statistics", "", func(c Change) {
+		changes = append(changes, c)
+	})
+	Subscribe("Server", "", func(c Change) {
+		t.Errorf("unexpected change %+v", c)
+	})
+
+	writeConf(t, path, "[Log]\nlevel = INFO\n[Statistics]\nstatInterval = 5\nstat = true\n")
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("changes = %+v, want 2", changes)
+	}
+	if changes[0] != (Change{Section: "Log", Key: "level", Old: "DEBUG", New: "INFO"}) {
+		t.Errorf("changes[0] = %+v", changes[0])
+	}
+	if changes[1] != (Change{Section: "Statistics", Key: "stat", New: "true"}) {
+		t.Errorf("changes[1] = %+v", changes[1])
+	}
+	if v := Config().Section("Server").Key("httpPort").String(); v != "10240" {
+		t.Errorf("httpPort = %q, want value set by code to survive reload", v)
+	}
+
+	writeConf(t, path, "[Log\nlevel = ERROR\n")
+	if err := Reload(); err == nil {
+		t.Fatal("reload of an invalid file should fail")
+	}
+	if v := Config().Section("Log").Key("level").String(); v != "INFO" {
+		t.Errorf("level = %q, want previous config kept", v)
+	}
+}
+
+func TestSubscribeInSubscriber(t *testing.T) {
+	Subscribe("Nested", "", func(c Change) {
+		Subscribe("Nested", "inner", func(Change) {})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		notify([]Change{{Section: "Nested", Key: "outer", New: "1"}})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("subscribe in a subscriber deadlocked")
+	}
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	called := 0
+	cancel := Subscribe("Cancel", "", func(Change) {
+		called++
+	})
+	notify([]Change{{Section: "Cancel", Key: "a", New: "1"}})
+	cancel()
+	notify([]Change{{Section: "Cancel", Key: "a", New: "2"}})
+	if called != 1 {
+		t.Errorf("called = %d, want 1 before cancel", called)
+	}
+	subLock.RLock()
+	n := len(subscribers["Cancel"])
+	subLock.RUnlock()
+	if n != 0 {
+		t.Errorf("subscribers = %d after cancel", n)
+	}
+}
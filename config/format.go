@@ -0,0 +1,167 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Section and Key are the lookup types of every format, a config in any
+// format is read through the same typed accessors such as MustInt, MustBool,
+// Duration and Strings(",").
+type (
+	Section = ini.Section
+	Key     = ini.Key
+)
+
+// DecodeFunc decodes a config file into sections. Values of a map are keys
+// of the section, nested maps are sections named "parent.child" and lists are
+// joined with ",".
+type DecodeFunc func(data []byte) (map[string]interface{}, error)
+
+var formats = map[string]DecodeFunc{
+	".yaml": decodeYaml,
+	".yml":  decodeYaml,
+	".json": decodeJson,
+	".toml": decodeToml,
+}
+
+// RegisterFormat makes files with extension ext, e.g. ".hcl", decoded by
+// decode. It must be called before the config is loaded.
+func RegisterFormat(ext string, decode DecodeFunc) {
+	formats[strings.ToLower(ext)] = decode
+}
+
+// LoadFile reads a config file, the format is chosen by the extension of
+// path. Files of unknown extension are read as ini.
+func LoadFile(path string) (*ini.File, error) {
+	decode, ok := formats[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return ini.Load(path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("config decode %s fail,%v", path, err)
+	}
+
+	f := ini.Empty()
+	if err := flatten(f, "", m); err != nil {
+		return nil, fmt.Errorf("config decode %s fail,%v", path, err)
+	}
+	return f, nil
+}
+
+func decodeYaml(data []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	err := yaml.Unmarshal(data, &m)
+	return m, err
+}
+
+func decodeJson(data []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	err := json.Unmarshal(data, &m)
+	return m, err
+}
+
+func decodeToml(data []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	err := toml.Unmarshal(data, &m)
+	return m, err
+}
+
+// flatten adds the values of m to the section named section, values at the
+// top level go to the default section.
+func flatten(f *ini.File, section string, m map[string]interface{}) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := m[k]
+		if child, ok := toMap(v); ok {
+			name := k
+			if section != "" {
+				name = section + "." + k
+			}
+			f.Section(name)
+			if err := flatten(f, name, child); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, err := toString(v)
+		if err != nil {
+			return fmt.Errorf("[%s] %s: %v", section, k, err)
+		}
+		if _, err := f.Section(section).NewKey(k, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			ret[fmt.Sprint(k)] = v
+		}
+		return ret, true
+	}
+	return nil, false
+}
+
+func toString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, e := range v {
+			if _, ok := toMap(e); ok {
+				return "", fmt.Errorf("list of maps not supported")
+			}
+			s, err := toString(e)
+			if err != nil {
+				return "", err
+			}
+			values[i] = s
+		}
+		return strings.Join(values, ","), nil
+	case []map[string]interface{}:
+		return "", fmt.Errorf("list of maps not supported")
+	}
+	return fmt.Sprint(v), nil
+}
@@ -0,0 +1,125 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var formatConfs = map[string]string{
+	"conf.ini": `
+[Process]
+maxCPU     = 2
+healthPort = 9527
+
+[Server]
+serverName = gd
+timeout    = 1.5s
+debug      = true
+
+[Mysql.test]
+master_ip   = 10.0.0.1,10.0.0.2
+master_port = 3306
+`,
+	"conf.yaml": `
+Process:
+  maxCPU: 2
+  healthPort: 9527
+Server:
+  serverName: gd
+  timeout: 1.5s
+  debug: true
+Mysql:
+  test:
+    master_ip: [10.0.0.1, 10.0.0.2]
+    master_port: 3306
+`,
+	"conf.json": `{
+  "Process": {"maxCPU": 2, "healthPort": 9527},
+  "Server": {"serverName": "gd", "timeout": "1.5s", "debug": true},
+  "Mysql": {"test": {"master_ip": ["10.0.0.1", "10.0.0.2"], "master_port": 3306}}
+}`,
+	"conf.toml": `
+[Process]
+maxCPU = 2
+healthPort = 9527
+
+[Server]
+serverName = "gd"
+timeout = "1.5s"
+debug = true
+
+[Mysql.test]
+master_ip = ["10.0.0.1", "10.0.0.2"]
+master_port = 3306
+`,
+}
+
+func TestLoadFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range formatConfs {
+		path := filepath.Join(dir, name)
+		writeConf(t, path, content)
+
+		c, err := Load(path)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if v := c.Section("Process").Key("maxCPU").MustInt(0); v != 2 {
+			t.Errorf("%s: maxCPU = %d, want 2", name, v)
+		}
+		if v := c.Section("Process").Key("healthPort").MustInt(0); v != 9527 {
+			t.Errorf("%s: healthPort = %d, want 9527", name, v)
+		}
+		if v := c.Section("Server").Key("serverName").String(); v != "gd" {
+			t.Errorf("%s: serverName = %q, want gd", name, v)
+		}
+		if v := c.Section("Server").Key("timeout").MustDuration(0); v != 1500*time.Millisecond {
+			t.Errorf("%s: timeout = %v, want 1.5s", name, v)
+		}
+		if v := c.Section("Server").Key("debug").MustBool(false); !v {
+			t.Errorf("%s: debug = false, want true", name)
+		}
+		if v := c.Section("Mysql.test").Key("master_ip").Strings(","); !reflect.DeepEqual(v, []string{"10.0.0.1", "10.0.0.2"}) {
+			t.Errorf("%s: master_ip = %v", name, v)
+		}
+		if v := c.Section("Mysql.test").Key("master_port").String(); v != "3306" {
+			t.Errorf("%s: master_port = %q, want 3306", name, v)
+		}
+	}
+}
+
+func TestLoadFormatInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"bad.yaml":  "Server: [",
+		"bad.json":  `{"Server": `,
+		"bad.toml":  "[Server\n",
+		"list.yaml": "Server:\n  hosts:\n    - {ip: 10.0.0.1}\n",
+	} {
+		path := filepath.Join(dir, name)
+		writeConf(t, path, content)
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: load should fail", name)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"fmt"
+	"gopkg.in/ini.v1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// EnvProfile selects the overlay of the config, e.g. GD_ENV=prod reads
+	// conf.prod.ini over conf.ini
+	EnvProfile = "GD_ENV"
+	// flagProfile is the flag that selects the overlay, it wins over GD_ENV
+	flagProfile = "--env="
+	// includeKey lists files read before the file that includes them
+	includeKey = "include"
+)
+
+var profile string
+
+// SetProfile selects the overlay of the config, it wins over the flag
+// --env=name and GD_ENV. It takes effect on the next Reload.
+func SetProfile(name string) {
+	lock.Lock()
+	profile = name
+	lock.Unlock()
+}
+
+// Profile returns the selected overlay, "" if none.
+func Profile() string {
+	lock.Lock()
+	defer lock.Unlock()
+	return currentProfile()
+}
+
+// currentProfile returns the profile, the caller holds lock.
+func currentProfile() string {
+	if profile != "" {
+		return profile
+	}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, flagProfile) {
+			return arg[len(flagProfile):]
+		}
+	}
+	return os.Getenv(EnvProfile)
+}
+
+// loadPath reads path, a file or a directory of fragments, and merges the
+// overlays of profile over it. The overlay of conf.ini is conf.<profile>.ini
+// next to it. The fragments of a directory are merged in order of their
+// names, followed by their overlays in the same order. Later files override
+// earlier ones key by key.
+func loadPath(path, profile string) (*ini.File, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files, overlays []string
+	if !fi.IsDir() {
+		files = append(files, path)
+		if o := overlayName(path, profile); o != "" && exists(o) {
+			overlays = append(overlays, o)
+		}
+	} else {
+		infos, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[string]bool)
+		for _, info := range infos {
+			if !info.IsDir() && isConfigFile(info.Name()) {
+				names[info.Name()] = true
+			}
+		}
+		// ReadDir sorts by name
+		for _, info := range infos {
+			name := info.Name()
+			if !names[name] || isOverlay(name, names) {
+				continue
+			}
+			files = append(files, filepath.Join(path, name))
+			if o := overlayName(name, profile); o != "" && names[o] {
+				overlays = append(overlays, filepath.Join(path, o))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no config file in %s", path)
+		}
+	}
+
+	f := ini.Empty()
+	for _, file := range append(files, overlays...) {
+		src, err := loadIncludes(file, nil)
+		if err != nil {
+			return nil, err
+		}
+		merge(f, src)
+	}
+	return f, nil
+}
+
+// loadIncludes reads a file after the files of its include key, a comma
+// separated list of paths relative to the file. stack detects cycles.
+func loadIncludes(path string, stack []string) (*ini.File, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range stack {
+		if p == abs {
+			return nil, fmt.Errorf("config include cycle %s", strings.Join(append(stack, abs), " -> "))
+		}
+	}
+
+	f, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	def := f.Section(ini.DefaultSection)
+	if !def.HasKey(includeKey) {
+		return f, nil
+	}
+	includes := def.Key(includeKey).Strings(",")
+	def.DeleteKey(includeKey)
+
+	ret := ini.Empty()
+	for _, inc := range includes {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(path), inc)
+		}
+		src, err := loadIncludes(inc, append(stack, abs))
+		if err != nil {
+			return nil, fmt.Errorf("config include %s of %s fail,%v", inc, path, err)
+		}
+		merge(ret, src)
+	}
+	merge(ret, f)
+	return ret, nil
+}
+
+// merge sets every key of src in dst.
+func merge(dst, src *ini.File) {
+	for _, s := range src.Sections() {
+		ds := dst.Section(s.Name())
+		for _, k := range s.Keys() {
+			ds.NewKey(k.Name(), k.String())
+		}
+	}
+}
+
+// overlayName returns conf.<profile>.ini of conf.ini, "" without profile.
+func overlayName(path, profile string) string {
+	if profile == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// isOverlay reports whether name is conf.<profile>.ini of a conf.ini in
+// names.
+func isOverlay(name string, names map[string]bool) bool {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	i := strings.LastIndex(stem, ".")
+	return i > 0 && names[stem[:i]+ext]
+}
+
+func isConfigFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	_, ok := formats[ext]
+	return ok || ext == ".ini"
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
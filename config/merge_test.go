@@ -0,0 +1,137 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeConf(t, path, content)
+	}
+	return dir
+}
+
+func TestLoadPathOverlay(t *testing.T) {
+	dir := writeDir(t, map[string]string{
+		"conf.ini":      "include = common/mysql.ini\n[Server]\nhttpPort = 10240\nserverName = gd\n",
+		"conf.prod.ini": "[Server]\nhttpPort = 80\n[Mysql.test]\nmaster_ip = 10.0.0.9\n",
+		"common/mysql.ini": "[Mysql.test]\nmaster_ip = 127.0.0.1\nmaster_port = 3306\n" +
+			"[Server]\nserverName = common\n",
+	})
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "conf.ini")
+
+	for _, tt := range []struct {
+		profile, httpPort, masterIp string
+	}{
+		{"", "10240", "127.0.0.1"},
+		{"prod", "80", "10.0.0.9"},
+		{"dev", "10240", "127.0.0.1"},
+	} {
+		f, err := loadPath(path, tt.profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := NewConf(f)
+		if v := c.Key("Server", "httpPort").String(); v != tt.httpPort {
+			t.Errorf("%q: httpPort = %q, want %q", tt.profile, v, tt.httpPort)
+		}
+		if v := c.Key("Mysql.test", "master_ip").String(); v != tt.masterIp {
+			t.Errorf("%q: master_ip = %q, want %q", tt.profile, v, tt.masterIp)
+		}
+		if v := c.Key("Mysql.test", "master_port").String(); v != "3306" {
+			t.Errorf("%q: master_port = %q, want 3306 from include", tt.profile, v)
+		}
+		if v := c.Key("Server", "serverName").String(); v != "gd" {
+			t.Errorf("%q: serverName = %q, want the file to override its include", tt.profile, v)
+		}
+		if f.Section("").HasKey("include") {
+			t.Errorf("%q: include key should be removed", tt.profile)
+		}
+	}
+}
+
+func TestLoadPathDir(t *testing.T) {
+	dir := writeDir(t, map[string]string{
+		"00-base.ini":         "[Server]\nhttpPort = 1\nrpcPort = 1\ngrpcPort = 1\n",
+		"10-server.yaml":      "Server:\n  rpcPort: 2\n",
+		"10-server.prod.yaml": "Server:\n  grpcPort: 3\n",
+		"20-server.json":      `{"Server": {"httpPort": 2}}`,
+		"README.md":           "not a config",
+	})
+	defer os.RemoveAll(dir)
+
+	for profile, want := range map[string][3]string{
+		"":     {"2", "2", "1"},
+		"prod": {"2", "2", "3"},
+	} {
+		f, err := loadPath(dir, profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := f.Section("Server")
+		got := [3]string{s.Key("httpPort").String(), s.Key("rpcPort").String(), s.Key("grpcPort").String()}
+		if got != want {
+			t.Errorf("%q: ports = %v, want %v", profile, got, want)
+		}
+	}
+
+	empty := writeDir(t, map[string]string{"README.md": ""})
+	defer os.RemoveAll(empty)
+	if _, err := loadPath(empty, ""); err == nil {
+		t.Error("dir without config file should fail")
+	}
+}
+
+func TestLoadPathIncludeCycle(t *testing.T) {
+	dir := writeDir(t, map[string]string{
+		"a.ini": "include = b.ini\n",
+		"b.ini": "include = a.ini\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := loadPath(filepath.Join(dir, "a.ini"), "")
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("err = %v, want include cycle", err)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	os.Setenv(EnvProfile, "staging")
+	defer os.Unsetenv(EnvProfile)
+	if p := Profile(); p != "staging" {
+		t.Errorf("profile = %q, want staging from env", p)
+	}
+
+	SetArgs([]string{"--env=prod", "-v"})
+	defer SetArgs(nil)
+	if p := Profile(); p != "prod" {
+		t.Errorf("profile = %q, want prod from flag", p)
+	}
+	if args := Args(); len(args) != 1 || args[0] != "-v" {
+		t.Errorf("args = %v, want [-v]", args)
+	}
+
+	SetProfile("dev")
+	defer SetProfile("")
+	if p := Profile(); p != "dev" {
+		t.Errorf("profile = %q, want dev set by code", p)
+	}
+}
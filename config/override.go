@@ -0,0 +1,312 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Xxianglei/gd/dlog"
+	"gopkg.in/ini.v1"
+	"os"
+	"strings"
+)
+
+// Sources of a value, from the lowest precedence to the highest.
+const (
+	SourceDefault = "default" // SetDefault
+	SourceFile    = "file"
+	SourceRemote  = "remote" // SetRemote
+	SourceEnv     = "env"    // GD_SECTION_KEY
+	SourceFlag    = "flag"   // --Section.key=value
+	SourceCode    = "code"   // SetValue
+)
+
+const EnvPrefix = "GD_"
+
+var (
+	defaults = make(map[string]map[string]string)
+	args     = os.Args[1:]
+)
+
+// Value is a resolved key and where its value comes from. The value of a
+// secret is Redacted.
+type Value struct {
+	Section string
+	Key     string
+	Value   string
+	Source  string
+	Secret  bool
+}
+
+type sourceKey struct {
+	section string
+	key     string
+}
+
+// SetDefault sets the value of a key that is used if no flag, env or file
+// sets it.
+func SetDefault(section, key, value string) {
+	lock.Lock()
+	setIn(defaults, section, key, value)
+	lock.Unlock()
+
+	c := Config()
+	var err error
+	switch c.Source(section, key) {
+	case "":
+		if v, ok := os.LookupEnv(EnvName(section, key)); ok {
+			err = c.set(section, key, v, SourceEnv)
+		} else {
+			err = c.set(section, key, value, SourceDefault)
+		}
+	case SourceDefault:
+		err = c.set(section, key, value, SourceDefault)
+	}
+	if err != nil {
+		dlog.Error("Config set default occur error:%v", err)
+	}
+}
+
+// SetArgs sets the command line read for --Section.key=value overrides,
+// default os.Args[1:]. It takes effect on the next Reload.
+func SetArgs(a []string) {
+	lock.Lock()
+	args = a
+	lock.Unlock()
+}
+
+// Args returns the command line without the config overrides and profile,
+// e.g. for flag.CommandLine.Parse(config.Args()).
+func Args() []string {
+	lock.Lock()
+	defer lock.Unlock()
+
+	var ret []string
+	for _, arg := range args {
+		if _, _, _, ok := parseFlag(arg); !ok && !strings.HasPrefix(arg, flagProfile) {
+			ret = append(ret, arg)
+		}
+	}
+	return ret
+}
+
+// EnvName returns the environment variable that overrides section/key, e.g.
+// GD_SERVER_HTTPPORT for [Server] httpPort.
+func EnvName(section, key string) string {
+	name := strings.ToUpper(EnvPrefix + section + "_" + key)
+	return strings.NewReplacer(".", "_", "-", "_").Replace(name)
+}
+
+// Key returns the effective value of section/key. Unlike Section().Key() it
+// also finds environment variables of keys that are not in the file if c is
+// returned by Config.
+func (c *Conf) Key(section, key string) *Key {
+	s := c.ini.Section(section)
+	if c.layered && !s.HasKey(key) {
+		if v, ok := os.LookupEnv(EnvName(section, key)); ok {
+			if err := c.set(section, key, v, SourceEnv); err != nil {
+				dlog.Error("Config env occur error:%v", err)
+			}
+		}
+	}
+	return s.Key(key)
+}
+
+// Source returns where the value of section/key comes from, "" if it is not
+// set.
+func (c *Conf) Source(section, key string) string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.sources[sourceKey{section, key}]
+}
+
+// Values returns every key that is set and its source, secrets are
+// redacted.
+func (c *Conf) Values() []Value {
+	var ret []Value
+	for _, s := range c.ini.Sections() {
+		for _, k := range s.Keys() {
+			source := c.Source(s.Name(), k.Name())
+			if source == "" {
+				continue
+			}
+			v := Value{Section: s.Name(), Key: k.Name(), Value: k.String(), Source: source}
+			if c.secret(s.Name(), k.Name()) {
+				v.Value, v.Secret = Redacted, true
+			}
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+func (c *Conf) secret(section, key string) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.secrets[sourceKey{section, key}]
+}
+
+// shown returns value to be shown in errors, Redacted if it is a secret of
+// the section or of a parent section.
+func (c *Conf) shown(section, key, value string) string {
+	for s := section; ; {
+		if c.secret(s, key) {
+			return Redacted
+		}
+		i := strings.LastIndex(s, ".")
+		if i < 0 {
+			return value
+		}
+		s = s[:i]
+	}
+}
+
+// set sets a key and resolves its secret references. If they cannot be
+// resolved the raw value is kept and the error names the key.
+func (c *Conf) set(section, key, value, source string) error {
+	v, secret, err := resolveSecret(value)
+
+	// Key would find the key of a parent section, e.g. [Mysql] of [Mysql.test]
+	c.ini.Section(section).NewKey(key, v)
+	c.lock.Lock()
+	if c.sources == nil {
+		c.sources = make(map[sourceKey]string)
+		c.secrets = make(map[sourceKey]bool)
+	}
+	k := sourceKey{section, key}
+	c.sources[k] = source
+	if secret {
+		c.secrets[k] = true
+	} else {
+		delete(c.secrets, k)
+	}
+	c.lock.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("[%s] %s: %v", section, key, err)
+	}
+	return nil
+}
+
+// errorList joins the errors of many keys.
+type errorList []string
+
+func (l *errorList) add(err error) {
+	if err != nil {
+		*l = append(*l, err.Error())
+	}
+}
+
+func (l errorList) err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(l, "; "))
+}
+
+// layer resolves the keys of a loaded file, with the keys of the remote
+// source rf merged in. Defaults fill keys missing in the file, environment
+// variables override the keys known by then or found by Key in prev, flags
+// and values set by code override any key. The caller holds lock.
+func layer(f, rf *ini.File, prev *Conf) (*Conf, error) {
+	c := &Conf{ini: f, layered: true}
+	var errs errorList
+	for _, s := range f.Sections() {
+		for _, k := range s.Keys() {
+			source := SourceFile
+			if hasOwnKey(rf, s.Name(), k.Name()) {
+				source = SourceRemote
+			}
+			errs.add(c.set(s.Name(), k.Name(), k.String(), source))
+		}
+	}
+
+	for section, keys := range defaults {
+		for key, value := range keys {
+			if !f.Section(section).HasKey(key) {
+				errs.add(c.set(section, key, value, SourceDefault))
+			}
+		}
+	}
+
+	for _, s := range f.Sections() {
+		for _, k := range s.Keys() {
+			if v, ok := os.LookupEnv(EnvName(s.Name(), k.Name())); ok {
+				errs.add(c.set(s.Name(), k.Name(), v, SourceEnv))
+			}
+		}
+	}
+
+	if prev != nil {
+		prev.lock.RLock()
+		var keys []sourceKey
+		for k, source := range prev.sources {
+			if source == SourceEnv && !f.Section(k.section).HasKey(k.key) {
+				keys = append(keys, k)
+			}
+		}
+		prev.lock.RUnlock()
+		for _, k := range keys {
+			if v, ok := os.LookupEnv(EnvName(k.section, k.key)); ok {
+				errs.add(c.set(k.section, k.key, v, SourceEnv))
+			}
+		}
+	}
+
+	for _, arg := range args {
+		if section, key, value, ok := parseFlag(arg); ok {
+			errs.add(c.set(section, key, value, SourceFlag))
+		}
+	}
+
+	for section, keys := range overrides {
+		for key, value := range keys {
+			errs.add(c.set(section, key, value, SourceCode))
+		}
+	}
+	return c, errs.err()
+}
+
+// parseFlag parses --Section.key=value, the key is after the last dot so
+// that sections such as Mysql.test can be set.
+func parseFlag(arg string) (section, key, value string, ok bool) {
+	if !strings.HasPrefix(arg, "--") {
+		return
+	}
+	arg = arg[2:]
+	eq := strings.Index(arg, "=")
+	if eq < 0 {
+		return
+	}
+	name := arg[:eq]
+	dot := strings.LastIndex(name, ".")
+	if dot <= 0 || dot == len(name)-1 {
+		return
+	}
+	return name[:dot], name[dot+1:], arg[eq+1:], true
+}
+
+// hasOwnKey is HasKey without the keys of parent sections.
+func hasOwnKey(f *ini.File, section, key string) bool {
+	if f == nil {
+		return false
+	}
+	s, err := f.GetSection(section)
+	if err != nil {
+		return false
+	}
+	_, ok := s.KeysHash()[key]
+	return ok
+}
+
+func setIn(m map[string]map[string]string, section, key, value string) {
+	keys, ok := m[section]
+	if !ok {
+		keys = make(map[string]string)
+		m[section] = keys
+	}
+	keys[key] = value
+}
@@ -0,0 +1,110 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLayer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "layer.ini")
+	writeConf(t, path, "[App]\nport = 1\nhost = file\nname = file\n")
+	SetConfPath(path)
+
+	SetArgs([]string{"--App.port=3", "-v", "--App.extra=flag"})
+	defer SetArgs(nil)
+	for k, v := range map[string]string{"GD_APP_PORT": "2", "GD_APP_HOST": "env", "GD_APP_ONLYENV": "env"} {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	SetDefault("App", "timeout", "5s")
+	SetDefault("App", "name", "default")
+
+	c := Config()
+	for _, tt := range []struct {
+		key, value, source string
+	}{
+		{"port", "3", SourceFlag},
+		{"host", "env", SourceEnv},
+		{"name", "file", SourceFile},
+		{"timeout", "5s", SourceDefault},
+		{"extra", "flag", SourceFlag},
+		{"onlyenv", "env", SourceEnv},
+	} {
+		if v := c.Key("App", tt.key).String(); v != tt.value {
+			t.Errorf("%s = %q, want %q", tt.key, v, tt.value)
+		}
+		if s := c.Source("App", tt.key); s != tt.source {
+			t.Errorf("%s source = %q, want %q", tt.key, s, tt.source)
+		}
+	}
+	if args := Args(); !reflect.DeepEqual(args, []string{"-v"}) {
+		t.Errorf("args = %v, want [-v]", args)
+	}
+
+	var values []Value
+	for _, v := range c.Values() {
+		if v.Section == "App" {
+			values = append(values, v)
+		}
+	}
+	if len(values) != 6 {
+		t.Errorf("values = %+v, want 6", values)
+	}
+
+	Subscribe("App", "", func(c Change) {
+		t.Errorf("unexpected change %+v", c)
+	})
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if s := Config().Source("App", "onlyenv"); s != SourceEnv {
+		t.Errorf("onlyenv source after reload = %q, want env", s)
+	}
+}
+
+func TestSetChildSection(t *testing.T) {
+	c := loadConf(t, "[Mysql]\nhost = parent\n[Mysql.test]\nport = 3306\n")
+	c.set("Mysql.test", "host", "child", SourceFlag)
+	if v := c.Key("Mysql", "host").String(); v != "parent" {
+		t.Errorf("parent host = %q, want parent", v)
+	}
+	if v := c.Key("Mysql.test", "host").String(); v != "child" {
+		t.Errorf("child host = %q, want child", v)
+	}
+}
+
+func TestParseFlag(t *testing.T) {
+	tests := []struct {
+		arg                 string
+		section, key, value string
+		ok                  bool
+	}{
+		{"--Server.httpPort=8080", "Server", "httpPort", "8080", true},
+		{"--Mysql.test.master_ip=a,b", "Mysql.test", "master_ip", "a,b", true},
+		{"--Server.name=", "Server", "name", "", true},
+		{"-Server.httpPort=8080", "", "", "", false},
+		{"--Server.httpPort", "", "", "", false},
+		{"--httpPort=8080", "", "", "", false},
+		{"--Server.=1", "", "", "", false},
+	}
+	for _, tt := range tests {
+		section, key, value, ok := parseFlag(tt.arg)
+		if section != tt.section || key != tt.key || value != tt.value || ok != tt.ok {
+			t.Errorf("parseFlag(%q) = %q, %q, %q, %v", tt.arg, section, key, value, ok)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Types of a declared key.
+const (
+	TypeString   = "string"
+	TypeBool     = "bool"
+	TypeInt      = "int"
+	TypeUint     = "uint"
+	TypeFloat    = "float"
+	TypeSize     = "size"     // Size
+	TypeDuration = "duration" // time.Duration
+	TypeList     = "list"     // []string split by ","
+)
+
+var types = map[string]reflect.Type{
+	TypeString:   reflect.TypeOf(""),
+	TypeBool:     reflect.TypeOf(false),
+	TypeInt:      reflect.TypeOf(int64(0)),
+	TypeUint:     reflect.TypeOf(uint64(0)),
+	TypeFloat:    reflect.TypeOf(float64(0)),
+	TypeSize:     sizeType,
+	TypeDuration: durationType,
+	TypeList:     reflect.TypeOf([]string(nil)),
+}
+
+// KeyDef declares a key that is read by the framework or the application.
+type KeyDef struct {
+	Section string
+	Key     string
+	Type    string // one of TypeString, TypeBool ..., default TypeString
+	Default string
+	Desc    string
+	Enum    string // allowed values separated by ","
+	Unit    string // unit of a duration set by a plain number, default "ms"
+}
+
+var (
+	regLock  sync.RWMutex
+	registry []KeyDef
+	declared = make(map[string]map[string]int) // section -> key -> index of registry
+)
+
+// Declare adds keys to the registry, a key declared again is replaced. The
+// registry validates the config, see Validate, and generates the sample,
+// see WriteSample.
+func Declare(defs ...KeyDef) {
+	regLock.Lock()
+	defer regLock.Unlock()
+	for _, d := range defs {
+		if d.Type == "" {
+			d.Type = TypeString
+		}
+		keys, ok := declared[d.Section]
+		if !ok {
+			keys = make(map[string]int)
+			declared[d.Section] = keys
+		}
+		if i, ok := keys[d.Key]; ok {
+			registry[i] = d
+			continue
+		}
+		keys[d.Key] = len(registry)
+		registry = append(registry, d)
+	}
+}
+
+// DeclareStruct declares the fields of a struct bound by Bind, e.g.
+// DeclareStruct("Server", ServerConf{}). The desc tag describes a field.
+func DeclareStruct(section string, v interface{}) error {
+	rt := reflect.TypeOf(v)
+	if rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return errors.New("config declare needs a struct")
+	}
+
+	var defs []KeyDef
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		key := f.Tag.Get("config")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = lowerFirst(f.Name)
+		}
+		typ, err := typeName(f.Type)
+		if err != nil {
+			return fmt.Errorf("config declare [%s] %s fail,%v", section, key, err)
+		}
+		defs = append(defs, KeyDef{
+			Section: section,
+			Key:     key,
+			Type:    typ,
+			Default: f.Tag.Get("default"),
+			Desc:    f.Tag.Get("desc"),
+			Enum:    f.Tag.Get("enum"),
+			Unit:    f.Tag.Get("unit"),
+		})
+	}
+	Declare(defs...)
+	return nil
+}
+
+// Declared returns the declared keys in order of declaration.
+func Declared() []KeyDef {
+	regLock.RLock()
+	defer regLock.RUnlock()
+	return append([]KeyDef(nil), registry...)
+}
+
+// Validate checks Config against the registry, see Conf.Validate.
+func Validate() error {
+	return Config().Validate()
+}
+
+// Validate reports the keys of declared sections that are not declared,
+// e.g. httpport instead of httpPort, and the declared keys whose values do
+// not match their type or enum. Keys set by SetDefault and SetValue are not
+// checked.
+func (c *Conf) Validate() error {
+	regLock.RLock()
+	defer regLock.RUnlock()
+
+	var errs errorList
+	for _, s := range c.ini.Sections() {
+		keys, ok := declared[s.Name()]
+		if !ok {
+			continue
+		}
+		for _, k := range s.Keys() {
+			switch c.Source(s.Name(), k.Name()) {
+			case "", SourceDefault, SourceCode:
+				continue
+			}
+			i, ok := keys[k.Name()]
+			if !ok {
+				errs.add(unknownKey(s.Name(), k.Name(), keys))
+				continue
+			}
+			if err := checkValue(registry[i], k.String(), c.shown(s.Name(), k.Name(), k.String())); err != nil {
+				errs.add(fmt.Errorf("[%s] %s: %v", s.Name(), k.Name(), err))
+			}
+		}
+	}
+	return errs.err()
+}
+
+// WriteSample writes a reference config of every declared key with its
+// description, type and default.
+func WriteSample(w io.Writer) error {
+	// group the keys by section, in order of the first key of each section
+	var sections []string
+	bySection := make(map[string][]KeyDef)
+	for _, d := range Declared() {
+		if _, ok := bySection[d.Section]; !ok {
+			sections = append(sections, d.Section)
+		}
+		bySection[d.Section] = append(bySection[d.Section], d)
+	}
+
+	var b strings.Builder
+	b.WriteString("; generated from the declared keys, every key is set to its default\n")
+	for _, section := range sections {
+		fmt.Fprintf(&b, "\n[%s]\n", section)
+		for _, d := range bySection[section] {
+			writeSampleKey(&b, d)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSampleKey(b *strings.Builder, d KeyDef) {
+	if d.Desc != "" {
+		fmt.Fprintf(b, "; %s\n", d.Desc)
+	}
+	fmt.Fprintf(b, "; type: %s", d.Type)
+	if d.Type == TypeDuration {
+		unit := d.Unit
+		if unit == "" {
+			unit = "ms"
+		}
+		fmt.Fprintf(b, ", a number of %s or e.g. 1m30s", unit)
+	}
+	if d.Enum != "" {
+		fmt.Fprintf(b, ", one of %s", d.Enum)
+	}
+	fmt.Fprintf(b, "\n%s = %s\n", d.Key, d.Default)
+}
+
+func unknownKey(section, key string, keys map[string]int) error {
+	for k := range keys {
+		if strings.EqualFold(k, key) {
+			return fmt.Errorf("[%s] %s: unknown key, did you mean %s", section, key, k)
+		}
+	}
+	return fmt.Errorf("[%s] %s: unknown key", section, key)
+}
+
+// checkValue checks value against d, errors show shown instead of value.
+func checkValue(d KeyDef, value, shown string) error {
+	if value == "" {
+		return nil
+	}
+	if d.Enum != "" && !contains(strings.Split(d.Enum, ","), value) {
+		return fmt.Errorf("%q not one of %s", shown, d.Enum)
+	}
+	t, ok := types[d.Type]
+	if !ok {
+		return fmt.Errorf("unknown type %s", d.Type)
+	}
+	return setShown(reflect.New(t).Elem(), value, shown, d.Unit)
+}
+
+func typeName(t reflect.Type) (string, error) {
+	switch t {
+	case sizeType:
+		return TypeSize, nil
+	case durationType:
+		return TypeDuration, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return TypeString, nil
+	case reflect.Bool:
+		return TypeBool, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return TypeInt, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeUint, nil
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return TypeList, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
@@ -0,0 +1,89 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+type regConf struct {
+	Port    int           `config:"port" default:"8080" desc:"port of the app"`
+	Mode    string        `config:"mode" enum:"fast,slow"`
+	Timeout time.Duration `config:"timeout" unit:"s"`
+	Hosts   []string      `config:"hosts"`
+}
+
+func TestValidate(t *testing.T) {
+	if err := DeclareStruct("Reg", regConf{}); err != nil {
+		t.Fatal(err)
+	}
+	Declare(KeyDef{Section: "Reg", Key: "ratio", Type: TypeFloat})
+
+	c := loadConf(t, "[Reg]\nport = 80\nPort = 81\nmode = fast\ntimeout = 5\nratio = 0.5\n[Other]\nany = 1\n")
+	err := c.Validate()
+	if err == nil || err.Error() != "[Reg] Port: unknown key, did you mean port" {
+		t.Errorf("err = %v", err)
+	}
+
+	c = loadConf(t, "[Reg]\nport = x\nmode = medium\ntimeout = 1m\nratio = 0.5\nhost = a\n")
+	err = c.Validate()
+	if err == nil {
+		t.Fatal("invalid config passed")
+	}
+	for _, want := range []string{`[Reg] port: invalid int "x"`, `[Reg] mode: "medium" not one of fast,slow`, "[Reg] host: unknown key"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %s", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "timeout") {
+		t.Errorf("err = %v, timeout is valid", err)
+	}
+
+	// keys set by code are not checked
+	f, _ := ini.Load([]byte("[Reg]\nport = 1\n"))
+	c = NewConf(f)
+	c.set("Reg", "extra", "1", SourceCode)
+	if err := c.Validate(); err != nil {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestWriteSample(t *testing.T) {
+	if err := DeclareStruct("Reg", regConf{}); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := WriteSample(&b); err != nil {
+		t.Fatal(err)
+	}
+	s := b.String()
+	for _, want := range []string{
+		"[Server]\n",
+		"; port of the app\n; type: int\nport = 8080\n",
+		"; type: duration, a number of s or e.g. 1m30s\ntimeout = \n",
+		"; type: string, one of fast,slow\nmode = \n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("sample has no %q", want)
+		}
+	}
+	if strings.Count(s, "[Reg]") != 1 {
+		t.Errorf("sample has %d [Reg]", strings.Count(s, "[Reg]"))
+	}
+
+	// the sample is a valid config
+	f, err := ini.Load([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewConf(f).Validate(); err != nil {
+		t.Errorf("sample invalid, %v", err)
+	}
+}
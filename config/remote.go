@@ -0,0 +1,67 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"fmt"
+	"github.com/Xxianglei/gd/dlog"
+	"gopkg.in/ini.v1"
+)
+
+// Remote is a source of sections merged over the config file, such as
+// ZkSource.
+type Remote interface {
+	// Load returns the sections of the source
+	Load() (*ini.File, error)
+	// Watch calls onChange when the sections may have changed
+	Watch(onChange func())
+	Close() error
+}
+
+var remote Remote
+
+// SetRemote merges the sections of r over the config file and reloads the
+// config whenever r changes. Its keys are below env and flags, see layer. A
+// nil r removes the remote source.
+func SetRemote(r Remote) error {
+	lock.Lock()
+	prev := remote
+	remote = r
+	lock.Unlock()
+
+	if prev != nil {
+		prev.Close()
+	}
+	if r != nil {
+		r.Watch(func() {
+			if err := Reload(); err != nil {
+				dlog.Error("Config remote reload occur error:%v", err)
+			}
+		})
+	}
+	if _, ok := getConf(defaultConfigName); !ok {
+		// Config loads it
+		return nil
+	}
+	return Reload()
+}
+
+// loadRemote returns the remote sections, nil without a remote source. The
+// caller must not hold lock, as the source may wait for the network.
+func loadRemote() (*ini.File, error) {
+	lock.Lock()
+	r := remote
+	lock.Unlock()
+
+	if r == nil {
+		return nil, nil
+	}
+	rf, err := r.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load remote config fail,%v", err)
+	}
+	return rf, nil
+}
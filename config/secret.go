@@ -0,0 +1,159 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// EnvSecretKey is the base64 AES key of 16, 24 or 32 bytes that decrypts
+	// ENC(...) values
+	EnvSecretKey = "GD_CONFIG_KEY"
+	// EnvSecretKeyFile is a file that contains the base64 key, it is read if
+	// GD_CONFIG_KEY is not set
+	EnvSecretKeyFile = "GD_CONFIG_KEY_FILE"
+
+	// Redacted replaces secrets in dumps and logs
+	Redacted = "******"
+)
+
+var (
+	refPattern = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+	encPattern = regexp.MustCompile(`^ENC\((.+)\)$`)
+)
+
+// GenerateKey returns a new base64 AES-256 key for GD_CONFIG_KEY.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// SecretKey reads the key of GD_CONFIG_KEY or GD_CONFIG_KEY_FILE.
+func SecretKey() ([]byte, error) {
+	s := os.Getenv(EnvSecretKey)
+	if s == "" {
+		path := os.Getenv(EnvSecretKeyFile)
+		if path == "" {
+			return nil, fmt.Errorf("neither %s nor %s set", EnvSecretKey, EnvSecretKeyFile)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s = string(b)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key,%v", err)
+	}
+	return key, nil
+}
+
+// Encrypt returns ENC(base64) of value, the base64 is the GCM nonce followed
+// by the sealed value.
+func Encrypt(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+	return "ENC(" + base64.StdEncoding.EncodeToString(sealed) + ")", nil
+}
+
+// Decrypt returns the value of ENC(base64) returned by Encrypt.
+func Decrypt(key []byte, enc string) (string, error) {
+	m := encPattern.FindStringSubmatch(enc)
+	if m == nil {
+		return "", errors.New("not an ENC(...) value")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(m[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid ENC base64,%v", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("ENC value too short")
+	}
+	value, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("ENC value cannot be decrypted")
+	}
+	return string(value), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// resolveSecret replaces ${env:NAME} and ${file:path} in value, or decrypts
+// a value of ENC(...). It reports whether value refers to a secret. Errors
+// never contain the secret.
+func resolveSecret(value string) (string, bool, error) {
+	if encPattern.MatchString(value) {
+		key, err := SecretKey()
+		if err != nil {
+			return value, true, err
+		}
+		plain, err := Decrypt(key, value)
+		if err != nil {
+			return value, true, err
+		}
+		return plain, true, nil
+	}
+
+	if !strings.Contains(value, "${") {
+		return value, false, nil
+	}
+	var err error
+	secret := false
+	ret := refPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		m := refPattern.FindStringSubmatch(ref)
+		secret = true
+		switch m[1] {
+		case "env":
+			v, ok := os.LookupEnv(m[2])
+			if !ok && err == nil {
+				err = fmt.Errorf("env %s not set", m[2])
+			}
+			return v
+		default:
+			b, e := ioutil.ReadFile(m[2])
+			if e != nil && err == nil {
+				err = e
+			}
+			return strings.TrimRight(string(b), "\r\n")
+		}
+	})
+	if err != nil {
+		return value, true, err
+	}
+	return ret, secret, nil
+}
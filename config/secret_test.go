@@ -0,0 +1,132 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	s, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := base64.StdEncoding.DecodeString(s)
+
+	enc, err := Encrypt(key, "p@ss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enc, "ENC(") || strings.Contains(enc, "p@ss") {
+		t.Fatalf("enc = %s", enc)
+	}
+	if v, err := Decrypt(key, enc); err != nil || v != "p@ss" {
+		t.Errorf("decrypt = %q, %v", v, err)
+	}
+
+	other, _ := GenerateKey()
+	key2, _ := base64.StdEncoding.DecodeString(other)
+	if _, err := Decrypt(key2, enc); err == nil {
+		t.Error("decrypt with another key succeeded")
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "db_pass")
+	writeConf(t, file, "from-file\n")
+
+	s, _ := GenerateKey()
+	os.Setenv(EnvSecretKey, s)
+	defer os.Unsetenv(EnvSecretKey)
+	key, _ := SecretKey()
+	enc, _ := Encrypt(key, "from-enc")
+
+	os.Setenv("TEST_DB_PASS", "from-env")
+	defer os.Unsetenv("TEST_DB_PASS")
+
+	for _, tt := range []struct {
+		value, want string
+		secret, err bool
+	}{
+		{"plain", "plain", false, false},
+		{"${env:TEST_DB_PASS}", "from-env", true, false},
+		{"u:${env:TEST_DB_PASS}@host", "u:from-env@host", true, false},
+		{"${file:" + file + "}", "from-file", true, false},
+		{enc, "from-enc", true, false},
+		{"${env:TEST_NOT_SET}", "${env:TEST_NOT_SET}", true, true},
+		{"${file:" + filepath.Join(dir, "none") + "}", "${file:" + filepath.Join(dir, "none") + "}", true, true},
+		{"ENC(bad)", "ENC(bad)", true, true},
+	} {
+		v, secret, err := resolveSecret(tt.value)
+		if v != tt.want || secret != tt.secret || (err != nil) != tt.err {
+			t.Errorf("resolve %s = %q, %v, %v", tt.value, v, secret, err)
+		}
+	}
+}
+
+func TestValuesRedacted(t *testing.T) {
+	os.Setenv("TEST_DB_PASS", "from-env")
+	defer os.Unsetenv("TEST_DB_PASS")
+
+	f, err := ini.Load([]byte("[Mysql]\nhost = db\npassword = ${env:TEST_DB_PASS}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewConf(f)
+	if v := c.Key("Mysql", "password").String(); v != "from-env" {
+		t.Errorf("password = %q", v)
+	}
+	for _, v := range c.Values() {
+		switch v.Key {
+		case "password":
+			if v.Value != Redacted || !v.Secret {
+				t.Errorf("password value = %+v", v)
+			}
+		case "host":
+			if v.Value != "db" || v.Secret {
+				t.Errorf("host value = %+v", v)
+			}
+		}
+	}
+
+	f, _ = ini.Load([]byte("[Mysql]\npassword = ${env:TEST_NOT_SET}\n"))
+	if _, err := newConf(f); err == nil || !strings.Contains(err.Error(), "[Mysql] password") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestBindErrorRedacted(t *testing.T) {
+	os.Setenv("TEST_DB_PORT", "s3cret")
+	defer os.Unsetenv("TEST_DB_PORT")
+
+	f, err := ini.Load([]byte("[Mysql]\nport = ${env:TEST_DB_PORT}\nmode = ${env:TEST_DB_PORT}\n[Mysql.test]\nhost = db\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewConf(f)
+	var conf struct {
+		Port int    `config:"port"`
+		Mode string `config:"mode" enum:"rw,ro"`
+	}
+	for _, section := range []string{"Mysql", "Mysql.test"} {
+		err := c.Bind(section, &conf)
+		if err == nil || strings.Contains(err.Error(), "s3cret") || !strings.Contains(err.Error(), Redacted) {
+			t.Errorf("%s: err = %v, want the secret redacted", section, err)
+		}
+	}
+}
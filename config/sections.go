@@ -0,0 +1,91 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import "time"
+
+func init() {
+	DeclareStruct("Log", LogConf{})
+	DeclareStruct("Server", ServerConf{})
+	DeclareStruct("Process", ProcessConf{})
+	DeclareStruct("Statistics", StatisticsConf{})
+	DeclareStruct("Register", RegisterConf{})
+	DeclareStruct("Client", ClientConf{})
+}
+
+// ServerConf is the [Server] section, a port of 0 disables its server.
+type ServerConf struct {
+	ServerName string `config:"serverName" desc:"name of the service"`
+	HttpPort   int    `config:"httpPort" min:"0" max:"65535" desc:"port of the http server, 0 disables it"`
+	RpcPort    int    `config:"rpcPort" min:"0" max:"65535" desc:"port of the rpc server, 0 disables it"`
+	GrpcPort   int    `config:"grpcPort" min:"0" max:"65535" desc:"port of the grpc server, 0 disables it"`
+}
+
+// LogConf is the [Log] section.
+type LogConf struct {
+	Enable bool   `config:"enable" desc:"write the logs to files of logDir"`
+	Level  string `config:"level" default:"DEBUG" enum:"DEBUG,INFO,WARNING,ERROR" desc:"lowest level logged, changes take effect on reload"`
+	LogDir string `config:"logDir" default:"log" desc:"directory of the log files"`
+	Format string `config:"format" default:"text" enum:"text,json" desc:"format of the log files, json writes a line of json per record"`
+
+	MaxBackups int           `config:"maxBackups" min:"0" desc:"rotated files kept of every log file, 0 keeps all of them"`
+	MaxAge     time.Duration `config:"maxAge" unit:"h" min:"0" desc:"hours after which rotated files are removed, 0 keeps them"`
+	Compress   bool          `config:"compress" desc:"gzip the rotated files"`
+
+	SampleFirst      int  `config:"sampleFirst" min:"0" desc:"logs of a call site written per second, then 1 in sampleThereafter, 0 writes all of them"`
+	SampleThereafter int  `config:"sampleThereafter" min:"0" desc:"1 in how many logs of a call site are written after sampleFirst, 0 drops them"`
+	Dedup            bool `config:"dedup" desc:"collapse identical logs of a call site into a repeated X times line"`
+
+	Policy       string        `config:"policy" default:"block-timeout" enum:"block,drop-newest,drop-oldest,block-timeout" desc:"what logging does when the buffer of a log file is full"`
+	BlockTimeout time.Duration `config:"blockTimeoutMs" unit:"ms" default:"2" min:"1" desc:"wait of the block-timeout policy before a log is dropped"`
+	FlushTimeout time.Duration `config:"flushTimeout" unit:"s" default:"3" min:"0" desc:"wait for the buffered logs to be written on exit"`
+
+	Routing bool `config:"routing" default:"true" desc:"write the SESSION logs to access.log and the *_SLOW logs to slow.log, and only their warnings and errors to the log files"`
+}
+
+// ProcessConf is the [Process] section. Memory sizes of 0 are derived from
+// the cgroup, see the README.
+type ProcessConf struct {
+	MaxCPU           int           `config:"maxCPU" min:"0" desc:"limit of cpus, 0 uses the cgroup quota or half of the cores"`
+	MaxMemory        Size          `config:"maxMemory" desc:"memory budget, 0 is 90% of the cgroup limit"`
+	MemorySoftLimit  Size          `config:"memorySoftLimit" desc:"heap that forces a gc and a heap profile, 0 is 80% of maxMemory"`
+	MemoryHardLimit  Size          `config:"memoryHardLimit" desc:"heap above which new requests are rejected, 0 is maxMemory"`
+	GCInterval       time.Duration `config:"gcInterval" unit:"s" min:"0" desc:"least interval of the gcs forced above memorySoftLimit, 0 is 10s"`
+	HeapDumpInterval time.Duration `config:"heapDumpInterval" unit:"s" min:"0" desc:"least interval of heap profiles"`
+	CgroupRoot       string        `config:"cgroupRoot" default:"/sys/fs/cgroup" desc:"mount point of the cgroup filesystem"`
+	HealthPort       int           `config:"healthPort" min:"0" max:"65535" desc:"port of the health server, 0 disables it"`
+	ProbePort        int           `config:"probePort" min:"0" max:"65535" desc:"port of /healthz and /readyz, 0 disables it"`
+	ProbeTimeout     time.Duration `config:"probeTimeoutMs" unit:"ms" default:"1000" min:"1" desc:"timeout of every readiness check"`
+	AdminToken       string        `config:"adminToken" desc:"bearer token of /admin/log/levels of the probe server, empty disables it"`
+	UpgradeTimeout   time.Duration `config:"upgradeTimeout" unit:"s" default:"30" min:"1" desc:"time for the new process of an upgrade to be ready"`
+	StrictConfig     bool          `config:"strictConfig" desc:"fail to start on unknown keys and invalid values of declared sections, instead of a warning"`
+}
+
+// StatisticsConf is the [Statistics] section.
+type StatisticsConf struct {
+	Falcon       bool          `config:"falcon" desc:"report perf counters to falcon"`
+	Stat         bool          `config:"stat" desc:"write statistics to stat.log of logDir"`
+	StatInterval time.Duration `config:"statInterval" unit:"s" default:"5" min:"1" desc:"interval of statistics"`
+}
+
+// RegisterConf is the [Register] section.
+type RegisterConf struct {
+	Enable    bool     `config:"enable" desc:"register the started servers"`
+	ZkHost    []string `config:"zkHost" desc:"addresses of zookeeper"`
+	EtcdHost  []string `config:"etcdHost" desc:"addresses of etcd, for an EtcdRegister of the application"`
+	Root      string   `config:"root" default:"root" desc:"root node of the services"`
+	Group     string   `config:"group" desc:"group of the service"`
+	Env       string   `config:"env" desc:"environment of the service, e.g. prod"`
+	Weight    uint64   `config:"weight" default:"10" desc:"weight of the node"`
+	Ip        string   `config:"ip" desc:"ip registered, default the ip of the host"`
+	DrainTime int      `config:"drainTime" default:"5" min:"0" desc:"seconds between unregistering and closing the servers"`
+}
+
+// ClientConf is the [Client] section of the clients made by the engine.
+type ClientConf struct {
+	HttpTimeout time.Duration `config:"httpTimeoutMs" unit:"ms" min:"0" desc:"timeout of the clients of gd.NewHttpClient, 0 keeps the one they are made with, changes take effect on reload"`
+	RpcTimeout  time.Duration `config:"rpcTimeoutMs" unit:"ms" min:"0" desc:"request timeout of the clients of gd.NewRpcClient, 0 keeps the one they are made with, changes take effect on reload"`
+}
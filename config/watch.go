@@ -0,0 +1,123 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"github.com/Xxianglei/gd/dlog"
+	"github.com/Xxianglei/gd/utls"
+	"gopkg.in/ini.v1"
+	"sync"
+)
+
+// Change describes one key whose value differs after a reload.
+// Old is empty for an added key and New is empty for a removed key.
+type Change struct {
+	Section string
+	Key     string
+	Old     string
+	New     string
+}
+
+type SubscribeFunc func(c Change)
+
+type subscriber struct {
+	key string
+	fn  SubscribeFunc
+}
+
+var (
+	subLock     sync.RWMutex
+	subscribers = make(map[string][]*subscriber)
+)
+
+// Subscribe registers fn to be called when section/key changes on Reload.
+// An empty key subscribes to every key of the section. The returned cancel
+// removes fn, e.g. when the object fn changes is stopped.
+func Subscribe(section, key string, fn SubscribeFunc) (cancel func()) {
+	s := &subscriber{key: key, fn: fn}
+	subLock.Lock()
+	subscribers[section] = append(subscribers[section], s)
+	subLock.Unlock()
+
+	return func() {
+		unsubscribe(section, s)
+	}
+}
+
+func unsubscribe(section string, s *subscriber) {
+	subLock.Lock()
+	defer subLock.Unlock()
+
+	var subs []*subscriber
+	for _, sub := range subscribers[section] {
+		if sub != s {
+			subs = append(subs, sub)
+		}
+	}
+	if len(subs) == 0 {
+		delete(subscribers, section)
+		return
+	}
+	subscribers[section] = subs
+}
+
+// notify calls the subscribers without holding subLock, so that they may
+// subscribe.
+func notify(changes []Change) {
+	for _, c := range changes {
+		subLock.RLock()
+		subs := append([]*subscriber(nil), subscribers[c.Section]...)
+		subLock.RUnlock()
+
+		for _, s := range subs {
+			if s.key != "" && s.key != c.Key {
+				continue
+			}
+			c, fn := c, s.fn
+			utls.WithRecover(func() {
+				fn(c)
+			}, func(err interface{}) {
+				dlog.Error("Config subscriber [%s] %s panic:%v", c.Section, c.Key, err)
+			})
+		}
+	}
+}
+
+// diff compares values only, a missing key equals an empty one. Lookups
+// through Section().Key() create empty keys on demand, so they never show up
+// as changes.
+func diff(old, new *ini.File) []Change {
+	var changes []Change
+	for _, s := range new.Sections() {
+		for _, k := range s.Keys() {
+			if ov := value(old, s.Name(), k.Name()); ov != k.String() {
+				changes = append(changes, Change{Section: s.Name(), Key: k.Name(), Old: ov, New: k.String()})
+			}
+		}
+	}
+
+	for _, s := range old.Sections() {
+		for _, k := range s.Keys() {
+			ns, err := new.GetSection(s.Name())
+			if (err != nil || !ns.HasKey(k.Name())) && k.String() != "" {
+				changes = append(changes, Change{Section: s.Name(), Key: k.Name(), Old: k.String()})
+			}
+		}
+	}
+	return changes
+}
+
+func value(f *ini.File, section, key string) string {
+	s, err := f.GetSection(section)
+	if err != nil {
+		return ""
+	}
+	k, err := s.GetKey(key)
+	if err != nil {
+		return ""
+	}
+	return k.String()
+}
@@ -0,0 +1,247 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Xxianglei/gd/dlog"
+	"github.com/samuel/go-zookeeper/zk"
+	"gopkg.in/ini.v1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ZkRetryInterval is how long ZkSource waits to read zk again after a
+// failure, e.g. when the process boots from the cache while zk is down.
+var ZkRetryInterval = 10 * time.Second
+
+// ZkConn is the part of *zk.Conn read by ZkSource.
+type ZkConn interface {
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
+	Close()
+}
+
+// ZkSource reads sections from a node tree of zk, e.g. /config/{service}/{env}.
+// Every child of the root is a section and its data are the keys of the
+// section in ini format:
+//
+//	/config/gd/prod/Server     httpPort = 8080
+//	/config/gd/prod/Mysql.test master_ip = 10.0.0.1
+//
+// It watches the root and the sections. The last sections read are saved in
+// a cache file, which is read while zk cannot be reached.
+type ZkSource struct {
+	conn  ZkConn
+	root  string
+	cache string
+
+	lock     sync.Mutex
+	gen      int                      // generation of the watches, events of older ones are ignored
+	watches  map[string]chan struct{} // stops the watch of a node
+	changed  chan int
+	closed   chan struct{}
+	onChange func()
+	once     sync.Once
+}
+
+// NewZkSource returns a source of the sections under root. cache is the file
+// of the last-known-good sections, "" disables it.
+func NewZkSource(conn ZkConn, root, cache string) *ZkSource {
+	return &ZkSource{
+		conn:    conn,
+		root:    strings.TrimRight(root, "/"),
+		cache:   cache,
+		watches: make(map[string]chan struct{}),
+		changed: make(chan int),
+		closed:  make(chan struct{}),
+	}
+}
+
+// ConnectZk connects to hosts and returns a source of the sections under
+// root, see NewZkSource. The connection is retried in the background, so it
+// does not fail if zk is down.
+func ConnectZk(hosts []string, root, cache string) (*ZkSource, error) {
+	conn, _, err := zk.Connect(hosts, time.Second*5, zk.WithLogInfo(false))
+	if err != nil {
+		return nil, err
+	}
+	return NewZkSource(conn, root, cache), nil
+}
+
+// Load reads the sections and watches them. If zk fails the cache is
+// returned and zk is read again after ZkRetryInterval.
+func (s *ZkSource) Load() (*ini.File, error) {
+	f, err := s.read()
+	if err == nil {
+		s.save(f)
+		return f, nil
+	}
+
+	s.retry()
+	if s.cache == "" {
+		return nil, err
+	}
+	dlog.Warn("Config zk read %s occur error, use cache %s:%v", s.root, s.cache, err)
+	f, cerr := ini.Load(s.cache)
+	if cerr != nil {
+		return nil, fmt.Errorf("zk read %s fail,%v, cache %s fail,%v", s.root, err, s.cache, cerr)
+	}
+	return f, nil
+}
+
+// Watch calls onChange when a section is added, removed or changed.
+func (s *ZkSource) Watch(onChange func()) {
+	s.lock.Lock()
+	s.onChange = onChange
+	s.lock.Unlock()
+	s.once.Do(func() {
+		go s.run()
+	})
+}
+
+// Close stops watching and closes the connection.
+func (s *ZkSource) Close() error {
+	select {
+	case <-s.closed:
+		return nil
+	default:
+		close(s.closed)
+	}
+	s.conn.Close()
+	return nil
+}
+
+func (s *ZkSource) read() (*ini.File, error) {
+	s.lock.Lock()
+	s.gen++
+	gen := s.gen
+	s.lock.Unlock()
+
+	sections, _, ch, err := s.conn.ChildrenW(s.root)
+	if err != nil {
+		return nil, err
+	}
+	s.watch(gen, s.root, ch)
+
+	sort.Strings(sections)
+	var buf bytes.Buffer
+	for _, name := range sections {
+		path := s.root + "/" + name
+		data, _, ch, err := s.conn.GetW(path)
+		if err == zk.ErrNoNode {
+			// removed after ChildrenW, its watch reloads
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.watch(gen, path, ch)
+		fmt.Fprintf(&buf, "[%s]\n%s\n", name, data)
+	}
+
+	f, err := ini.Load(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid sections of %s,%v", s.root, err)
+	}
+	return f, nil
+}
+
+// watch reports the first event of ch as a change of generation gen. It
+// stops the previous watch of path, so that a node has one watch at most.
+func (s *ZkSource) watch(gen int, path string, ch <-chan zk.Event) {
+	stop := make(chan struct{})
+	s.lock.Lock()
+	if prev, ok := s.watches[path]; ok {
+		close(prev)
+	}
+	s.watches[path] = stop
+	s.lock.Unlock()
+
+	go func() {
+		select {
+		case ev, ok := <-ch:
+			if !ok || ev.Type == zk.EventNotWatching {
+				// the watches are lost, e.g. the session expired, read zk
+				// again to set them
+				select {
+				case <-s.closed:
+				default:
+					dlog.Warn("Config zk watch %s lost, read again after %v", path, ZkRetryInterval)
+					s.retry()
+				}
+				return
+			}
+			s.notify(gen)
+		case <-stop:
+		case <-s.closed:
+		}
+	}()
+}
+
+func (s *ZkSource) retry() {
+	s.lock.Lock()
+	gen := s.gen
+	s.lock.Unlock()
+
+	time.AfterFunc(ZkRetryInterval, func() {
+		s.notify(gen)
+	})
+}
+
+func (s *ZkSource) notify(gen int) {
+	select {
+	case s.changed <- gen:
+	case <-s.closed:
+	}
+}
+
+func (s *ZkSource) run() {
+	for {
+		select {
+		case gen := <-s.changed:
+			s.lock.Lock()
+			current, onChange := s.gen, s.onChange
+			s.lock.Unlock()
+			if gen == current && onChange != nil {
+				onChange()
+			}
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// save writes the cache through a temporary file, so that a crash does not
+// leave a partial cache.
+func (s *ZkSource) save(f *ini.File) {
+	if s.cache == "" {
+		return
+	}
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		dlog.Warn("Config zk cache %s occur error:%v", s.cache, err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.cache), 0755); err != nil {
+		dlog.Warn("Config zk cache %s occur error:%v", s.cache, err)
+		return
+	}
+	tmp := s.cache + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		dlog.Warn("Config zk cache %s occur error:%v", s.cache, err)
+		return
+	}
+	if err := os.Rename(tmp, s.cache); err != nil {
+		dlog.Warn("Config zk cache %s occur error:%v", s.cache, err)
+	}
+}
@@ -0,0 +1,287 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// fakeZk is a ZkConn of nodes in memory.
+type fakeZk struct {
+	lock    sync.Mutex
+	nodes   map[string]string
+	watches map[string][]chan zk.Event
+	down    bool
+}
+
+func newFakeZk(nodes map[string]string) *fakeZk {
+	return &fakeZk{nodes: nodes, watches: make(map[string][]chan zk.Event)}
+}
+
+func (z *fakeZk) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	if z.down {
+		return nil, nil, nil, zk.ErrNoServer
+	}
+	var children []string
+	for p := range z.nodes {
+		if strings.HasPrefix(p, path+"/") {
+			children = append(children, p[len(path)+1:])
+		}
+	}
+	return children, &zk.Stat{}, z.addWatch(path), nil
+}
+
+func (z *fakeZk) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	if z.down {
+		return nil, nil, nil, zk.ErrNoServer
+	}
+	data, ok := z.nodes[path]
+	if !ok {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	return []byte(data), &zk.Stat{}, z.addWatch(path), nil
+}
+
+func (z *fakeZk) Close() {}
+
+func (z *fakeZk) addWatch(path string) chan zk.Event {
+	ch := make(chan zk.Event, 1)
+	z.watches[path] = append(z.watches[path], ch)
+	return ch
+}
+
+// set changes a node and fires the watches of the node and its parent.
+func (z *fakeZk) set(path, data string) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	typ := zk.EventNodeDataChanged
+	if _, ok := z.nodes[path]; !ok {
+		typ = zk.EventNodeCreated
+	}
+	z.nodes[path] = data
+	z.fire(path, typ)
+	z.fire(filepath.Dir(path), zk.EventNodeChildrenChanged)
+}
+
+func (z *fakeZk) fire(path string, typ zk.EventType) {
+	for _, ch := range z.watches[path] {
+		ch <- zk.Event{Type: typ, Path: path}
+	}
+	delete(z.watches, path)
+}
+
+// expire ends the session, all the watches get EventNotWatching.
+func (z *fakeZk) expire() {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	for path, chs := range z.watches {
+		for _, ch := range chs {
+			ch <- zk.Event{Type: zk.EventNotWatching, State: zk.StateDisconnected, Path: path, Err: zk.ErrSessionExpired}
+			close(ch)
+		}
+	}
+	z.watches = make(map[string][]chan zk.Event)
+}
+
+func TestZkSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "conf.ini")
+	writeConf(t, path, "[Remote]\nport = 10240\nhost = file\n")
+	SetConfPath(path)
+	Config()
+
+	z := newFakeZk(map[string]string{
+		"/config/gd/prod/Remote":     "port = 8080",
+		"/config/gd/prod/Mysql.test": "master_ip = 10.0.0.1",
+	})
+	cache := filepath.Join(dir, "cache", "zk.ini")
+	if err := SetRemote(NewZkSource(z, "/config/gd/prod/", cache)); err != nil {
+		t.Fatal(err)
+	}
+	defer SetRemote(nil)
+
+	c := Config()
+	for _, tt := range []struct {
+		section, key, value, source string
+	}{
+		{"Remote", "port", "8080", SourceRemote},
+		{"Remote", "host", "file", SourceFile},
+		{"Mysql.test", "master_ip", "10.0.0.1", SourceRemote},
+	} {
+		if v := c.Key(tt.section, tt.key).String(); v != tt.value {
+			t.Errorf("[%s] %s = %q, want %q", tt.section, tt.key, v, tt.value)
+		}
+		if s := c.Source(tt.section, tt.key); s != tt.source {
+			t.Errorf("[%s] %s source = %q, want %q", tt.section, tt.key, s, tt.source)
+		}
+	}
+
+	changed := make(chan Change, 1)
+	Subscribe("Remote", "port", func(c Change) {
+		select {
+		case changed <- c:
+		default:
+		}
+	})
+	z.set("/config/gd/prod/Remote", "port = 9090")
+	select {
+	case c := <-changed:
+		if c.Old != "8080" || c.New != "9090" {
+			t.Errorf("change = %+v", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("change of zk not pushed")
+	}
+
+	b, err := ioutil.ReadFile(cache)
+	if err != nil || !strings.Contains(string(b), "9090") {
+		t.Errorf("cache = %q, %v", b, err)
+	}
+}
+
+func TestZkSourceWatchOnce(t *testing.T) {
+	z := newFakeZk(map[string]string{
+		"/config/gd/prod/Remote": "port = 8080",
+		"/config/gd/prod/Mysql":  "host = db",
+	})
+	s := NewZkSource(z, "/config/gd/prod", "")
+	defer s.Close()
+
+	if _, err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	first := s.watches["/config/gd/prod/Remote"]
+	if _, err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.watches) != 3 {
+		t.Errorf("watches = %d, want one of the root and each section", len(s.watches))
+	}
+	select {
+	case <-first:
+	default:
+		t.Error("previous watch of a section not stopped")
+	}
+}
+
+func TestZkSourceSessionExpired(t *testing.T) {
+	z := newFakeZk(map[string]string{"/config/Server": "httpPort = 8080"})
+
+	interval := ZkRetryInterval
+	ZkRetryInterval = 10 * time.Millisecond
+	defer func() { ZkRetryInterval = interval }()
+
+	s := NewZkSource(z, "/config", "")
+	defer s.Close()
+	if _, err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := make(chan string, 10)
+	s.Watch(func() {
+		if f, err := s.Load(); err == nil {
+			reloaded <- f.Section("Server").Key("httpPort").String()
+		}
+	})
+
+	// the change is missed while the session is expired
+	z.lock.Lock()
+	z.nodes["/config/Server"] = "httpPort = 9090"
+	z.lock.Unlock()
+	z.expire()
+	select {
+	case v := <-reloaded:
+		if v != "9090" {
+			t.Errorf("httpPort = %q, want 9090", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("zk not read again after the session expired")
+	}
+
+	// and the watches are set again
+	z.set("/config/Server", "httpPort = 7070")
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case v := <-reloaded:
+			if v == "7070" {
+				return
+			}
+		case <-deadline:
+			t.Fatal("change after the session expired not pushed")
+		}
+	}
+}
+
+func TestZkSourceCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := filepath.Join(dir, "zk.ini")
+	writeConf(t, cache, "[Server]\nhttpPort = 8080\n")
+
+	z := newFakeZk(map[string]string{"/config/Server": "httpPort = 9090"})
+	z.down = true
+
+	interval := ZkRetryInterval
+	ZkRetryInterval = 10 * time.Millisecond
+	defer func() { ZkRetryInterval = interval }()
+
+	s := NewZkSource(z, "/config", cache)
+	defer s.Close()
+	f, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := f.Section("Server").Key("httpPort").String(); v != "8080" {
+		t.Errorf("httpPort = %q, want the cache", v)
+	}
+
+	// zk is read again once it is up
+	reloaded := make(chan struct{}, 1)
+	s.Watch(func() {
+		if f, err := s.Load(); err == nil && f.Section("Server").Key("httpPort").String() == "9090" {
+			reloaded <- struct{}{}
+		}
+	})
+	z.lock.Lock()
+	z.down = false
+	z.lock.Unlock()
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("zk not read again")
+	}
+
+	if _, err := NewZkSource(z, "/config", "").Load(); err != nil {
+		t.Errorf("load = %v", err)
+	}
+	z.lock.Lock()
+	z.down = true
+	z.lock.Unlock()
+	if _, err := NewZkSource(z, "/config", filepath.Join(dir, "none.ini")).Load(); err == nil {
+		t.Error("load without zk and cache succeeded")
+	}
+}
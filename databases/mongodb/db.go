@@ -9,6 +9,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/Xxianglei/gd/config"
 	"github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/runtime/gl"
 	"github.com/Xxianglei/gd/runtime/pc"
@@ -29,8 +30,6 @@ const (
 	glMongoCall     = "mongo_call"
 	glMongoCost     = "mongo_cost"
 	glMongoCallFail = "mongo_call_fail"
-
-	defaultConf = "conf/conf.ini"
 )
 
 type MongoConfig struct {
@@ -68,12 +67,11 @@ func (m *MongoClient) Start() error {
 		if m.DbConfig != nil {
 			err = m.initWithMongoConfig(m.DbConfig)
 		} else if m.DbConf != nil {
-			err = m.initDbs(m.DbConf, m.DataBase)
+			err = m.initDbs(config.NewConf(m.DbConf), m.DataBase)
+		} else if m.DbConfPath == "" {
+			// the config of the process, with overrides of env and flags
+			err = m.initDbs(config.Config(), m.DataBase)
 		} else {
-			if m.DbConfPath == "" {
-				m.DbConfPath = defaultConf
-			}
-
 			err = m.initObjForMongoDb(m.DbConfPath)
 		}
 	})
@@ -91,17 +89,44 @@ func (m *MongoClient) Close() {
 	})
 }
 
+// Name, Init, Health and Stop make MongoClient a gd.Component
+func (m *MongoClient) Name() string {
+	if m.DbConfig != nil {
+		return "mongo." + m.DbConfig.DataBase
+	}
+	return "mongo." + m.DataBase
+}
+
+func (m *MongoClient) Init() error {
+	return nil
+}
+
+func (m *MongoClient) Health() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return m.Check(ctx)
+}
+
+// Check pings the server until ctx is done, it is a health.Checker
+func (m *MongoClient) Check(ctx context.Context) error {
+	if m.client == nil {
+		return errors.New("mongoClient not started")
+	}
+	return m.client.Ping(ctx, nil)
+}
+
+func (m *MongoClient) Stop() error {
+	m.Close()
+	return nil
+}
+
 func (m *MongoClient) initObjForMongoDb(filePath string) error {
 	dbConfRealPath := filePath
 	if dbConfRealPath == "" {
 		return errors.New("dbConf not set in g_cfg")
 	}
 
-	if !strings.HasSuffix(dbConfRealPath, ".ini") {
-		return errors.New("dbConf not an ini file")
-	}
-
-	dbConf, err := ini.Load(dbConfRealPath)
+	dbConf, err := config.Load(dbConfRealPath)
 	if err != nil {
 		return err
 	}
@@ -112,7 +137,7 @@ func (m *MongoClient) initObjForMongoDb(filePath string) error {
 	return nil
 }
 
-func (m *MongoClient) initDbs(f *ini.File, db string) error {
+func (m *MongoClient) initDbs(f *config.Conf, db string) error {
 	c := f.Section(fmt.Sprintf("%s.%s", "Mongo", db))
 	hosts := c.Key("hosts").Strings(",")
 	userName := c.Key("user").String()
@@ -6,9 +6,11 @@
 package mysqldb
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/Xxianglei/gd/config"
 	log "github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/runtime/pc"
 	"gopkg.in/ini.v1"
@@ -22,8 +24,6 @@ import (
 )
 
 const (
-	defaultDbConf = "conf/conf.ini"
-
 	PcTransactionInsertDup = "transaction_insert_dup"
 )
 
@@ -46,12 +46,11 @@ func (c *MysqlClient) Start() error {
 		if c.DbConfig != nil {
 			err = c.initDbsWithCommonConf(c.DbConfig)
 		} else if c.DbConf != nil {
-			err = c.initDbs(c.DbConf, c.DataBases)
+			err = c.initDbs(config.NewConf(c.DbConf), c.DataBases)
+		} else if c.DbConfPath == "" {
+			// the config of the process, with overrides of env and flags
+			err = c.initDbs(config.Config(), c.DataBases)
 		} else {
-			if c.DbConfPath == "" {
-				c.DbConfPath = defaultDbConf
-			}
-
 			err = c.initObjForMysqlDb(c.DbConfPath)
 		}
 	})
@@ -64,6 +63,45 @@ func (c *MysqlClient) Close() {
 	})
 }
 
+// Name, Init, Health and Stop make MysqlClient a gd.Component
+func (c *MysqlClient) Name() string {
+	if c.DbConfig != nil {
+		return "mysql." + c.DbConfig.DbName
+	}
+	return "mysql." + c.DataBases
+}
+
+func (c *MysqlClient) Init() error {
+	return nil
+}
+
+// Health pings master and slaves for 3s at most
+func (c *MysqlClient) Health() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return c.Check(ctx)
+}
+
+// Check pings master and slaves until ctx is done, it is a health.Checker
+func (c *MysqlClient) Check(ctx context.Context) error {
+	for _, dbw := range c.getWriteDbsArray() {
+		if err := dbw.PingContext(ctx); err != nil {
+			return fmt.Errorf("ping master %s fail,%v", dbw.host, err)
+		}
+	}
+	for _, dbr := range c.getReadDbs() {
+		if err := dbr.PingContext(ctx); err != nil {
+			return fmt.Errorf("ping slave %s fail,%v", dbr.host, err)
+		}
+	}
+	return nil
+}
+
+func (c *MysqlClient) Stop() error {
+	c.Close()
+	return nil
+}
+
 func (c *MysqlClient) getReadDbs() []*DbWrap {
 	return c.dbRead
 }
@@ -105,23 +143,44 @@ func getHostFromConnStr(connStr string) (string, error) {
 	fi := strings.Index(connStr, "@") + 5
 	ei := strings.Index(connStr, "/") - 1
 	if fi <= 0 || ei <= 0 || ei <= fi {
-		err := fmt.Errorf("not find host in db conn str:%s,fi=%d,ei=%d", connStr, fi, ei)
+		err := fmt.Errorf("not find host in db conn str:%s,fi=%d,ei=%d", redactDSN(connStr), fi, ei)
 		return "", err
 	}
 
 	host := connStr[fi:ei]
 	if host == "" || host == ":" {
-		return "", fmt.Errorf("host not found %s", connStr)
+		return "", fmt.Errorf("host not found %s", redactDSN(connStr))
 	}
 
-	log.Debug("find host in db conn str:%s for %s", host, connStr)
+	log.Debug("find host in db conn str:%s for %s", host, redactDSN(connStr))
 	return host, nil
 }
 
+// redactDSN hides the password of user:password@tcp(host)/db in logs.
+func redactDSN(connStr string) string {
+	at := strings.LastIndex(connStr, "@")
+	if at < 0 {
+		return connStr
+	}
+	colon := strings.Index(connStr[:at], ":")
+	if colon < 0 {
+		return connStr
+	}
+	return connStr[:colon+1] + config.Redacted + connStr[at:]
+}
+
+func redactDSNs(connStrs []string) []string {
+	ret := make([]string, len(connStrs))
+	for i, s := range connStrs {
+		ret[i] = redactDSN(s)
+	}
+	return ret
+}
+
 func (c *MysqlClient) initMainDbsMaxOpen(connMasters []string, connSlaves []string, maxOpen int, maxIdle int, glSuffix string, timeout time.Duration, masterProxy, slaveProxy bool) error {
-	log.Debug("open master=%v,slave=%v", connMasters, connSlaves)
+	log.Debug("open master=%v,slave=%v", redactDSNs(connMasters), redactDSNs(connSlaves))
 	if len(connMasters) <= 0 {
-		return fmt.Errorf("masters empty,master=%v,slave=%v", connMasters, connSlaves)
+		return fmt.Errorf("masters empty,master=%v,slave=%v", redactDSNs(connMasters), redactDSNs(connSlaves))
 	}
 
 	var dbWrites []*DbWrap
@@ -11,7 +11,7 @@ import (
 	"strings"
 	"time"
 
-	"gopkg.in/ini.v1"
+	"github.com/Xxianglei/gd/config"
 )
 
 const defaultCharSet = "utf8mb4"
@@ -23,11 +23,7 @@ func (c *MysqlClient) initObjForMysqlDb(dbConfPath string) error {
 		return errors.New("dbConf not set in g_cfg")
 	}
 
-	if !strings.HasSuffix(dbConfRealPath, ".ini") {
-		return errors.New("dbConf not an ini file")
-	}
-
-	dbConf, err := ini.Load(dbConfRealPath)
+	dbConf, err := config.Load(dbConfRealPath)
 	if err != nil {
 		return err
 	}
@@ -38,7 +34,7 @@ func (c *MysqlClient) initObjForMysqlDb(dbConfPath string) error {
 	return nil
 }
 
-func (c *MysqlClient) initDbs(f *ini.File, db string) error {
+func (c *MysqlClient) initDbs(f *config.Conf, db string) error {
 	m := f.Section(fmt.Sprintf("%s.%s", "Mysql", db))
 	s := f.Section(fmt.Sprintf("%s.%s", "MysqlSlave", db))
 
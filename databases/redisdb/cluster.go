@@ -6,10 +6,12 @@
 package redisdb
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/Xxianglei/gd/config"
 	log "github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/runtime/gl"
 	"github.com/Xxianglei/gd/runtime/gr"
@@ -99,12 +101,11 @@ func (r *RedisClusterClient) Start() error {
 		if r.RedisConfig != nil {
 			err = r.newRedisCluster(r.RedisConfig)
 		} else if r.RedisConf != nil {
-			err = r.initRedisCluster(r.RedisConf, r.ClusterName)
+			err = r.initRedisCluster(config.NewConf(r.RedisConf), r.ClusterName)
+		} else if r.RedisConfPath == "" {
+			// the config of the process, with overrides of env and flags
+			err = r.initRedisCluster(config.Config(), r.ClusterName)
 		} else {
-			if r.RedisConfPath == "" {
-				r.RedisConfPath = defaultConf
-			}
-
 			err = r.initObjForRedisCluster(r.RedisConfPath)
 		}
 	})
@@ -127,6 +128,19 @@ func (r *RedisClusterClient) Close() {
 	})
 }
 
+// Check sends PING to every node until ctx is done, it is a health.Checker
+func (r *RedisClusterClient) Check(ctx context.Context) error {
+	if r.redisCluster == nil || r.redisCluster.clusterClient == nil {
+		return errors.New("redis cluster not started")
+	}
+	return r.redisCluster.clusterClient.WithContext(ctx).ForEachNode(func(client *redis.Client) error {
+		if err := client.WithContext(ctx).Ping().Err(); err != nil {
+			return fmt.Errorf("ping %s fail,%v", client.Options().Addr, err)
+		}
+		return nil
+	})
+}
+
 func (r *RedisClusterClient) newRedisCluster(clusterConf *RedisClusterConf) error {
 	if clusterConf == nil {
 		return errors.New("redisClusterConf is nil")
@@ -225,7 +239,7 @@ func (r *RedisClusterClient) newRedisCluster(clusterConf *RedisClusterConf) erro
 	return nil
 }
 
-func (r *RedisClusterClient) initRedisCluster(f *ini.File, cn string) error {
+func (r *RedisClusterClient) initRedisCluster(f *config.Conf, cn string) error {
 	c := f.Section(fmt.Sprintf("%s.%s", "Redis", cn))
 	addr := c.Key("addr").String()
 	poolSize, _ := c.Key("poolSize").Int()
@@ -268,11 +282,7 @@ func (r *RedisClusterClient) initObjForRedisCluster(redisConfPath string) error
 		return errors.New("redisConf not set in g_cfg")
 	}
 
-	if !strings.HasSuffix(redisConfRealPath, ".ini") {
-		return errors.New("redisConf not an ini file")
-	}
-
-	redisConf, err := ini.Load(redisConfRealPath)
+	redisConf, err := config.Load(redisConfRealPath)
 	if err != nil {
 		return err
 	}
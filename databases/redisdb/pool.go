@@ -6,8 +6,10 @@
 package redisdb
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"github.com/Xxianglei/gd/config"
 	log "github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/runtime/gl"
 	"github.com/Xxianglei/gd/runtime/gr"
@@ -39,8 +41,6 @@ const (
 	glRedisPoolCall     = "redisPool_call"
 	glRedisPoolCost     = "redisPool_cost"
 	glRedisPoolCallFail = "redisPool_call_fail"
-
-	defaultConf = "conf/conf.ini"
 )
 
 type RedisConfig struct {
@@ -79,12 +79,11 @@ func (p *RedisPoolClient) Start() error {
 		if p.RedisConfig != nil {
 			err = p.newRedisPools(p.RedisConfig)
 		} else if p.RedisConf != nil {
-			err = p.initRedis(p.RedisConf, p.PoolName)
+			err = p.initRedis(config.NewConf(p.RedisConf), p.PoolName)
+		} else if p.RedisConfPath == "" {
+			// the config of the process, with overrides of env and flags
+			err = p.initRedis(config.Config(), p.PoolName)
 		} else {
-			if p.RedisConfPath == "" {
-				p.RedisConfPath = defaultConf
-			}
-
 			err = p.initObjForRedisDb(p.RedisConfPath)
 		}
 	})
@@ -111,6 +110,49 @@ func (p *RedisPoolClient) Close() {
 	})
 }
 
+// Name, Init, Health and Stop make RedisPoolClient a gd.Component
+func (p *RedisPoolClient) Name() string {
+	return "redis." + p.PoolName
+}
+
+func (p *RedisPoolClient) Init() error {
+	return nil
+}
+
+// Health sends PING to every server for 3s at most
+func (p *RedisPoolClient) Health() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return p.Check(ctx)
+}
+
+// Check sends PING to every server until ctx is done, it is a health.Checker
+func (p *RedisPoolClient) Check(ctx context.Context) error {
+	if p.redisPool == nil {
+		return errors.New("redis pool not started")
+	}
+	for server, pool := range p.redisPool.p {
+		conn, err := pool.GetContext(ctx)
+		if err == nil {
+			if deadline, ok := ctx.Deadline(); ok {
+				_, err = redis.DoWithTimeout(conn, time.Until(deadline), "PING")
+			} else {
+				_, err = conn.Do("PING")
+			}
+			conn.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("ping %s fail,%v", server, err)
+		}
+	}
+	return nil
+}
+
+func (p *RedisPoolClient) Stop() error {
+	p.Close()
+	return nil
+}
+
 func (p *RedisPoolClient) newRedisPools(cfg *RedisConfig) error {
 	if len(cfg.Addrs) <= 0 {
 		return errors.New("servers empty")
@@ -152,6 +194,9 @@ func (p *RedisPoolClient) newRedisPools(cfg *RedisConfig) error {
 	cfg4Log.ConnTimeoutMs = int64(connTimeout / time.Millisecond)
 	cfg4Log.ReadTimeoutMs = int64(readTimeout / time.Millisecond)
 	cfg4Log.WriteTimeoutMs = int64(writeTimeout / time.Millisecond)
+	if cfg4Log.Password != "" {
+		cfg4Log.Password = config.Redacted
+	}
 
 	p.redisPool = newPoolRetryTimeout(cfg.Addrs, cfg.Password, cfg.DbNumber, maxActive, maxIdle, idleTimeout, retry, connTimeout, readTimeout, writeTimeout)
 	log.Info("start redis pool,server=%v,cfg=%v", p.redisPool.servers, &cfg4Log)
@@ -216,11 +261,7 @@ func (p *RedisPoolClient) initObjForRedisDb(redisConfPath string) error {
 		return errors.New("redisConf not set in g_cfg")
 	}
 
-	if !strings.HasSuffix(redisConfRealPath, ".ini") {
-		return errors.New("redisConf not an ini file")
-	}
-
-	redisConf, err := ini.Load(redisConfRealPath)
+	redisConf, err := config.Load(redisConfRealPath)
 	if err != nil {
 		return err
 	}
@@ -231,7 +272,7 @@ func (p *RedisPoolClient) initObjForRedisDb(redisConfPath string) error {
 	return nil
 }
 
-func (p *RedisPoolClient) initRedis(f *ini.File, pn string) error {
+func (p *RedisPoolClient) initRedis(f *config.Conf, pn string) error {
 	r := f.Section(fmt.Sprintf("%s.%s", "Redis", pn))
 	addr := r.Key("addr").String()
 	password := r.Key("password").String()
@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 type xmlProperty struct {
@@ -26,11 +27,21 @@ type xmlFilter struct {
 	Level    string        `xml:"level"`
 	Type     string        `xml:"type"`
 	Property []xmlProperty `xml:"property"`
+	Route    *xmlRoute     `xml:"route"`
+}
+
+type xmlSampleRule struct {
+	Tag        string `xml:"tag"`
+	First      int    `xml:"first"`
+	Thereafter int    `xml:"thereafter"`
+	Period     string `xml:"period"`
+	Dedup      bool   `xml:"dedup"`
 }
 
 type xmlLoggerConfig struct {
-	ScribeCategory string      `xml:"scribeCategory"`
-	Filter         []xmlFilter `xml:"filter"`
+	ScribeCategory string          `xml:"scribeCategory"`
+	Filter         []xmlFilter     `xml:"filter"`
+	Sampling       []xmlSampleRule `xml:"sampling>rule"`
 }
 
 // Load XML configuration; see examples/example.xml for documentation
@@ -69,6 +80,11 @@ func (log Logger) _loadConfiguration(filename string) {
 		os.Exit(1)
 	}
 
+	rules, ok := xmlToSampleRules(filename, xc.Sampling)
+	if !ok {
+		os.Exit(1)
+	}
+
 	newLoggers := make(map[string]*Filter)
 	for _, xmlfilt := range xc.Filter {
 		var filt LogWriter
@@ -95,28 +111,18 @@ func (log Logger) _loadConfiguration(filename string) {
 			bad = true
 		}
 
-		switch xmlfilt.Level {
-		case "FINEST":
-			lvl = FINEST
-		case "FINE":
-			lvl = FINE
-		case "DEBUG":
-			lvl = DEBUG
-		case "TRACE":
-			lvl = TRACE
-		case "INFO":
-			lvl = INFO
-		case "WARNING":
-			lvl = WARNING
-		case "ERROR":
-			lvl = ERROR
-		case "CRITICAL":
-			lvl = CRITICAL
-		default:
+		if l, ok := ParseLevel(xmlfilt.Level); ok {
+			lvl = l
+		} else {
 			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required child <%s> for filter has unknown value in %s: %s\n", "level", filename, xmlfilt.Level)
 			bad = true
 		}
 
+		route, ok := xmlToRoute(filename, xmlfilt.Route)
+		if !ok {
+			bad = true
+		}
+
 		// Just so all of the required attributes are errored at the same time if missing
 		if bad {
 			os.Exit(1)
@@ -135,7 +141,16 @@ func (log Logger) _loadConfiguration(filename string) {
 		case "xml":
 			filt, good = xmlToXMLLogWriter(filename, xmlfilt.Property, enabled)
 		case "socket":
-			filt, good = xmlToSocketLogWriter(filename, xmlfilt.Property, enabled)
+			// a socket that cannot be dialed is a nil writer, skipped below
+			filtsock, goodsock := xmlToSocketLogWriter(filename, xmlfilt.Property, enabled)
+			if filtsock != nil {
+				filt = filtsock
+			}
+			good = goodsock
+		case "network":
+			filt, good = xmlToNetLogWriter(filename, xmlfilt.Property, enabled, false)
+		case "syslog":
+			filt, good = xmlToNetLogWriter(filename, xmlfilt.Property, enabled, true)
 		default:
 			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Could not load XML configuration in %s: unknown filter type \"%s\"\n", filename, xmlfilt.Type)
 			os.Exit(1)
@@ -150,19 +165,61 @@ func (log Logger) _loadConfiguration(filename string) {
 		if !enabled {
 			continue
 		}
+		if filt == nil {
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Filter \"%s\" is skipped, its writer could not be created in %s\n", xmlfilt.Tag, filename)
+			continue
+		}
 
-		newLoggers[xmlfilt.Tag] = &Filter{lvl, filt}
+		newLoggers[xmlfilt.Tag] = &Filter{Level: lvl, LogWriter: filt, Route: route}
 	}
 
 	for k, v := range newLoggers {
 		log[k] = v
 	}
+	SetSampleRules(rules...)
+}
+
+func xmlToSampleRules(filename string, xrs []xmlSampleRule) ([]SampleRule, bool) {
+	var rules []SampleRule
+	good := true
+	for _, xr := range xrs {
+		rule := SampleRule{
+			Tag:        strings.Trim(xr.Tag, " \r\n"),
+			First:      xr.First,
+			Thereafter: xr.Thereafter,
+			Dedup:      xr.Dedup,
+		}
+		if period := strings.Trim(xr.Period, " \r\n"); period != "" {
+			d, err := time.ParseDuration(period)
+			if err != nil || d <= 0 {
+				fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Invalid period %q of sampling rule in %s\n", xr.Period, filename)
+				good = false
+				continue
+			}
+			rule.Period = d
+		}
+		rules = append(rules, rule)
+	}
+	return rules, good
 }
 
 func xmlToConsoleLogWriter(filename string, props []xmlProperty, enabled bool) (*ConsoleLogWriter, bool) {
+	format := ""
+	policy := ""
+	var timeout time.Duration
+
 	// Parse properties
 	for _, prop := range props {
 		switch prop.Name {
+		case "format":
+			format = strings.Trim(prop.Value, " \r\n")
+		case "policy":
+			policy = strings.Trim(prop.Value, " \r\n")
+			if _, ok := ParsePolicy(policy); !ok {
+				fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown policy \"%s\" for console filter in %s\n", policy, filename)
+			}
+		case "timeout":
+			timeout, _ = time.ParseDuration(strings.Trim(prop.Value, " \r\n"))
 		default:
 			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for console filter in %s\n", prop.Name, filename)
 		}
@@ -173,7 +230,12 @@ func xmlToConsoleLogWriter(filename string, props []xmlProperty, enabled bool) (
 		return nil, true
 	}
 
-	return NewConsoleLogWriter(), true
+	clw := NewConsoleLogWriter()
+	if format != "" {
+		clw.SetFormat(format)
+	}
+	clw.queue.configure(policy, timeout)
+	return clw, true
 }
 
 // Parse a number with K/M/G suffixes based on thousands (1000) or 2^10 (1024)
@@ -204,12 +266,23 @@ func xmlToFileLogWriter(filename string, props []xmlProperty, enabled bool) (*Fi
 	daily := false
 	hourly := false
 	rotate := false
+	maxbackups := 0
+	var maxage time.Duration
+	compress := false
+	policy := ""
+	var timeout time.Duration
 
 	// Parse properties
 	for _, prop := range props {
 		switch prop.Name {
 		case "filename":
 			file = strings.Trim(prop.Value, " \r\n")
+		case "maxbackups":
+			maxbackups, _ = strconv.Atoi(strings.Trim(prop.Value, " \r\n"))
+		case "maxage":
+			maxage = parseMaxAge(strings.Trim(prop.Value, " \r\n"))
+		case "compress":
+			compress = strings.Trim(prop.Value, " \r\n") != "false"
 		case "format":
 			format = strings.Trim(prop.Value, " \r\n")
 		case "maxlines":
@@ -222,6 +295,13 @@ func xmlToFileLogWriter(filename string, props []xmlProperty, enabled bool) (*Fi
 			hourly = strings.Trim(prop.Value, " \r\n") != "false"
 		case "rotate":
 			rotate = strings.Trim(prop.Value, " \r\n") != "false"
+		case "policy":
+			policy = strings.Trim(prop.Value, " \r\n")
+			if _, ok := ParsePolicy(policy); !ok {
+				fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown policy \"%s\" for file filter in %s\n", policy, filename)
+			}
+		case "timeout":
+			timeout, _ = time.ParseDuration(strings.Trim(prop.Value, " \r\n"))
 		default:
 			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for file filter in %s\n", prop.Name, filename)
 		}
@@ -244,6 +324,10 @@ func xmlToFileLogWriter(filename string, props []xmlProperty, enabled bool) (*Fi
 	flw.SetRotateSize(maxsize)
 	flw.SetRotateDaily(daily)
 	flw.SetRotateHourly(hourly)
+	flw.SetMaxBackups(maxbackups)
+	flw.SetMaxAge(maxage)
+	flw.SetCompress(compress)
+	flw.queue.configure(policy, timeout)
 	return flw, true
 }
 
@@ -254,12 +338,23 @@ func xmlToXMLLogWriter(filename string, props []xmlProperty, enabled bool) (*Fil
 	daily := false
 	hourly := false
 	rotate := false
+	maxbackups := 0
+	var maxage time.Duration
+	compress := false
+	policy := ""
+	var timeout time.Duration
 
 	// Parse properties
 	for _, prop := range props {
 		switch prop.Name {
 		case "filename":
 			file = strings.Trim(prop.Value, " \r\n")
+		case "maxbackups":
+			maxbackups, _ = strconv.Atoi(strings.Trim(prop.Value, " \r\n"))
+		case "maxage":
+			maxage = parseMaxAge(strings.Trim(prop.Value, " \r\n"))
+		case "compress":
+			compress = strings.Trim(prop.Value, " \r\n") != "false"
 		case "maxrecords":
 			maxrecords = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1000)
 		case "maxsize":
@@ -270,6 +365,13 @@ func xmlToXMLLogWriter(filename string, props []xmlProperty, enabled bool) (*Fil
 			hourly = strings.Trim(prop.Value, " \r\n") != "false"
 		case "rotate":
 			rotate = strings.Trim(prop.Value, " \r\n") != "false"
+		case "policy":
+			policy = strings.Trim(prop.Value, " \r\n")
+			if _, ok := ParsePolicy(policy); !ok {
+				fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown policy \"%s\" for xml filter in %s\n", policy, filename)
+			}
+		case "timeout":
+			timeout, _ = time.ParseDuration(strings.Trim(prop.Value, " \r\n"))
 		default:
 			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for xml filter in %s\n", prop.Name, filename)
 		}
@@ -291,12 +393,19 @@ func xmlToXMLLogWriter(filename string, props []xmlProperty, enabled bool) (*Fil
 	xlw.SetRotateSize(maxsize)
 	xlw.SetRotateDaily(daily)
 	xlw.SetRotateHourly(hourly)
+	xlw.SetMaxBackups(maxbackups)
+	xlw.SetMaxAge(maxage)
+	xlw.SetCompress(compress)
+	xlw.queue.configure(policy, timeout)
 	return xlw, true
 }
 
-func xmlToSocketLogWriter(filename string, props []xmlProperty, enabled bool) (SocketLogWriter, bool) {
+func xmlToSocketLogWriter(filename string, props []xmlProperty, enabled bool) (*SocketLogWriter, bool) {
 	endpoint := ""
 	protocol := "udp"
+	format := ""
+	policy := ""
+	var timeout time.Duration
 
 	// Parse properties
 	for _, prop := range props {
@@ -305,6 +414,15 @@ func xmlToSocketLogWriter(filename string, props []xmlProperty, enabled bool) (S
 			endpoint = strings.Trim(prop.Value, " \r\n")
 		case "protocol":
 			protocol = strings.Trim(prop.Value, " \r\n")
+		case "format":
+			format = strings.Trim(prop.Value, " \r\n")
+		case "policy":
+			policy = strings.Trim(prop.Value, " \r\n")
+			if _, ok := ParsePolicy(policy); !ok {
+				fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown policy \"%s\" for socket filter in %s\n", policy, filename)
+			}
+		case "timeout":
+			timeout, _ = time.ParseDuration(strings.Trim(prop.Value, " \r\n"))
 		default:
 			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for file filter in %s\n", prop.Name, filename)
 		}
@@ -321,5 +439,84 @@ func xmlToSocketLogWriter(filename string, props []xmlProperty, enabled bool) (S
 		return nil, true
 	}
 
-	return NewSocketLogWriter(protocol, endpoint), true
+	slw := NewSocketLogWriterFormat(protocol, endpoint, format)
+	if slw != nil {
+		slw.queue.configure(policy, timeout)
+	}
+	return slw, true
+}
+
+// xmlToNetLogWriter parses a network filter, or a syslog filter of RFC5424
+// framing.
+func xmlToNetLogWriter(filename string, props []xmlProperty, enabled bool, syslog bool) (*NetLogWriter, bool) {
+	endpoint := ""
+	protocol := "tcp"
+	format := ""
+	facility := DefaultSyslogFacility
+	appName := ""
+	spool := 0
+	var minBackoff, maxBackoff time.Duration
+	policy := ""
+	var timeout time.Duration
+
+	// Parse properties
+	for _, prop := range props {
+		value := strings.Trim(prop.Value, " \r\n")
+		switch prop.Name {
+		case "endpoint":
+			endpoint = value
+		case "protocol":
+			protocol = value
+		case "format":
+			format = value
+		case "facility":
+			facility, _ = strconv.Atoi(value)
+		case "appname":
+			appName = value
+		case "spool":
+			spool = strToNumSuffix(value, 1000)
+		case "minbackoff":
+			minBackoff, _ = time.ParseDuration(value)
+		case "maxbackoff":
+			maxBackoff, _ = time.ParseDuration(value)
+		case "policy":
+			policy = value
+			if _, ok := ParsePolicy(policy); !ok {
+				fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown policy \"%s\" for network filter in %s\n", policy, filename)
+			}
+		case "timeout":
+			timeout, _ = time.ParseDuration(value)
+		default:
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Warning: Unknown property \"%s\" for network filter in %s\n", prop.Name, filename)
+		}
+	}
+
+	// Check properties
+	if len(endpoint) == 0 {
+		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Required property \"%s\" for network filter missing in %s\n", "endpoint", filename)
+		return nil, false
+	}
+	switch protocol {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix", "unixgram":
+	default:
+		fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Unknown protocol \"%s\" for network filter in %s\n", protocol, filename)
+		return nil, false
+	}
+
+	// If it's disabled, we're just checking syntax
+	if !enabled {
+		return nil, true
+	}
+
+	nlw := NewNetLogWriter(protocol, endpoint)
+	if format != "" {
+		nlw.SetFormat(format)
+	}
+	if syslog {
+		nlw.SetSyslog(facility, appName)
+	}
+	nlw.SetSpool(spool)
+	nlw.SetBackoff(minBackoff, maxBackoff)
+	nlw.queue.configure(policy, timeout)
+	return nlw, true
 }
@@ -0,0 +1,141 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import "context"
+
+type ctxKey struct{}
+
+// ctxValues are the log values of a context, they are copied on change.
+type ctxValues struct {
+	logId    string
+	clientIp string
+	url      string
+	fields   []Field
+}
+
+func valuesOf(ctx context.Context) *ctxValues {
+	if ctx == nil {
+		return nil
+	}
+	v, _ := ctx.Value(ctxKey{}).(*ctxValues)
+	return v
+}
+
+func withValues(ctx context.Context, set func(v *ctxValues)) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	v := &ctxValues{}
+	if old := valuesOf(ctx); old != nil {
+		*v = *old
+	}
+	set(v)
+	return context.WithValue(ctx, ctxKey{}, v)
+}
+
+// WithLogId returns a copy of ctx that logs with logId, see InfoCtx.
+func WithLogId(ctx context.Context, logId string) context.Context {
+	return withValues(ctx, func(v *ctxValues) { v.logId = logId })
+}
+
+// WithClientIp returns a copy of ctx that logs with clientIp.
+func WithClientIp(ctx context.Context, clientIp string) context.Context {
+	return withValues(ctx, func(v *ctxValues) { v.clientIp = clientIp })
+}
+
+// WithUrl returns a copy of ctx that logs with url.
+func WithUrl(ctx context.Context, url string) context.Context {
+	return withValues(ctx, func(v *ctxValues) { v.url = url })
+}
+
+// WithFields returns a copy of ctx that logs with the fields of key value
+// pairs, see With.
+func WithFields(ctx context.Context, kv ...interface{}) context.Context {
+	return withValues(ctx, func(v *ctxValues) {
+		fields := make([]Field, 0, len(v.fields)+len(kv)/2)
+		fields = append(fields, v.fields...)
+		v.fields = append(fields, toFields(kv)...)
+	})
+}
+
+// LogIdFromContext returns the logId of ctx, or "".
+func LogIdFromContext(ctx context.Context) string {
+	if v := valuesOf(ctx); v != nil {
+		return v.logId
+	}
+	return ""
+}
+
+// ClientIpFromContext returns the client ip of ctx, or "".
+func ClientIpFromContext(ctx context.Context) string {
+	if v := valuesOf(ctx); v != nil {
+		return v.clientIp
+	}
+	return ""
+}
+
+// UrlFromContext returns the url of ctx, or "".
+func UrlFromContext(ctx context.Context) string {
+	if v := valuesOf(ctx); v != nil {
+		return v.url
+	}
+	return ""
+}
+
+// FieldsFromContext returns the fields of ctx.
+func FieldsFromContext(ctx context.Context) []Field {
+	if v := valuesOf(ctx); v != nil {
+		return v.fields
+	}
+	return nil
+}
+
+// FromContext returns an Entry of Global that logs with the values of ctx.
+// The logId, client ip and url missing in ctx are read from gl, so code
+// that still sets gl logs the same.
+func FromContext(ctx context.Context) *Entry {
+	return Global.FromContext(ctx)
+}
+
+// FromContext returns an Entry of log that logs with the values of ctx.
+func (log Logger) FromContext(ctx context.Context) *Entry {
+	return &Entry{logger: log, ctx: ctx}
+}
+
+// DebugCtx logs at the debug level with the values of ctx, see Debug for
+// the arguments.
+func DebugCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.FromContext(ctx).log(DEBUG, arg0, args...)
+}
+
+// TraceCtx logs at the trace level with the values of ctx.
+func TraceCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.FromContext(ctx).log(TRACE, arg0, args...)
+}
+
+// InfoCtx logs at the info level with the values of ctx, e.g.
+//
+//	ctx = dlog.WithLogId(ctx, logId)
+//	go func() { dlog.InfoCtx(ctx, "done %d", n) }()
+func InfoCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.FromContext(ctx).log(INFO, arg0, args...)
+}
+
+// WarnCtx logs at the warning level with the values of ctx.
+func WarnCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.FromContext(ctx).log(WARNING, arg0, args...)
+}
+
+// ErrorCtx logs at the error level with the values of ctx.
+func ErrorCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.FromContext(ctx).log(ERROR, arg0, args...)
+}
+
+// CriticalCtx logs at the critical level with the values of ctx.
+func CriticalCtx(ctx context.Context, arg0 interface{}, args ...interface{}) {
+	Global.FromContext(ctx).log(CRITICAL, arg0, args...)
+}
@@ -0,0 +1,64 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Xxianglei/gd/runtime/gl"
+)
+
+func TestFromContext(t *testing.T) {
+	w := &recordWriter{}
+	l := make(Logger)
+	l.AddFilter("rec", INFO, w)
+
+	ctx := WithLogId(context.Background(), "log1")
+	ctx = WithClientIp(ctx, "10.0.0.1")
+	ctx = WithFields(ctx, "uid", 10)
+	child := WithFields(ctx, "step", 2)
+	if LogIdFromContext(child) != "log1" || ClientIpFromContext(child) != "10.0.0.1" || UrlFromContext(child) != "" {
+		t.Errorf("values = %q %q %q", LogIdFromContext(child), ClientIpFromContext(child), UrlFromContext(child))
+	}
+	if len(FieldsFromContext(ctx)) != 1 || len(FieldsFromContext(child)) != 2 {
+		t.Errorf("fields = %v, of child %v", FieldsFromContext(ctx), FieldsFromContext(child))
+	}
+
+	// the url missing in ctx is read from gl, the logId of ctx wins
+	gl.Init()
+	gl.Set(gl.LogId, "gl")
+	gl.Set(gl.Url, "/api")
+	l.FromContext(child).With("cost", 3).Info("in handler")
+	gl.Close()
+
+	// a goroutine has no gl
+	done := make(chan struct{})
+	go func() {
+		l.FromContext(child).Tag("ASYNC").Warn("in goroutine")
+		close(done)
+	}()
+	<-done
+
+	if len(w.recs) != 2 {
+		t.Fatalf("%d records, want 2", len(w.recs))
+	}
+	rec := w.recs[0]
+	if rec.LogId != "log1" || rec.Ip != "10.0.0.1" || rec.Url != "/api" {
+		t.Errorf("record = %+v", rec)
+	}
+	if len(rec.Fields) != 3 || rec.Fields[0].Key != "uid" || rec.Fields[2].Key != "cost" {
+		t.Errorf("fields = %v", rec.Fields)
+	}
+	rec = w.recs[1]
+	if rec.LogId != "log1" || rec.Url != "" || rec.Tag != "ASYNC" {
+		t.Errorf("record = %+v", rec)
+	}
+
+	if v := valuesOf(nil); v != nil {
+		t.Errorf("values of nil = %+v", v)
+	}
+}
@@ -0,0 +1,270 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// FORMAT_JSON writes every record as a line of json, with the fields of
+// With next to time, level, tag, logId, clientIp, url, source and msg.
+const FORMAT_JSON = "json"
+
+// reserved keys of a json line, a field of the same key is renamed to
+// "fields.<key>"
+var reservedKeys = map[string]bool{
+	"time": true, "level": true, "tag": true, "logId": true,
+	"clientIp": true, "url": true, "source": true, "msg": true,
+}
+
+// Field is a typed value of a record, it is written as json.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Entry logs records with fields, see With.
+type Entry struct {
+	logger Logger
+	tag    string
+	fields []Field
+	ctx    context.Context // see FromContext
+}
+
+// With returns an Entry of Global with the fields of key value pairs, e.g.
+//
+//	dlog.With("uid", uid, "cost", cost).Info("login")
+//
+// A key that is not a string is formatted by %v, a key without value gets
+// the value nil.
+func With(kv ...interface{}) *Entry {
+	return Global.With(kv...)
+}
+
+// With returns an Entry of log with the fields of key value pairs.
+func (log Logger) With(kv ...interface{}) *Entry {
+	return &Entry{logger: log, fields: toFields(kv)}
+}
+
+// With returns a copy of e with more fields.
+func (e *Entry) With(kv ...interface{}) *Entry {
+	fields := make([]Field, 0, len(e.fields)+len(kv)/2)
+	fields = append(fields, e.fields...)
+	return &Entry{logger: e.logger, tag: e.tag, fields: append(fields, toFields(kv)...), ctx: e.ctx}
+}
+
+// Tag returns a copy of e that logs with tag, e.g. "SESSION".
+func (e *Entry) Tag(tag string) *Entry {
+	return &Entry{logger: e.logger, tag: tag, fields: e.fields, ctx: e.ctx}
+}
+
+// Fields returns the fields of e, after the fields of its context.
+func (e *Entry) Fields() []Field {
+	ctxFields := FieldsFromContext(e.ctx)
+	if len(ctxFields) == 0 {
+		return e.fields
+	}
+	fields := make([]Field, 0, len(ctxFields)+len(e.fields))
+	fields = append(fields, ctxFields...)
+	return append(fields, e.fields...)
+}
+
+// Debug logs at the debug level, see Logger.Debug for the arguments.
+func (e *Entry) Debug(arg0 interface{}, args ...interface{}) {
+	e.log(DEBUG, arg0, args...)
+}
+
+// Trace logs at the trace level.
+func (e *Entry) Trace(arg0 interface{}, args ...interface{}) {
+	e.log(TRACE, arg0, args...)
+}
+
+// Info logs at the info level.
+func (e *Entry) Info(arg0 interface{}, args ...interface{}) {
+	e.log(INFO, arg0, args...)
+}
+
+// Warn logs at the warning level.
+func (e *Entry) Warn(arg0 interface{}, args ...interface{}) {
+	e.log(WARNING, arg0, args...)
+}
+
+// Error logs at the error level.
+func (e *Entry) Error(arg0 interface{}, args ...interface{}) {
+	e.log(ERROR, arg0, args...)
+}
+
+// Critical logs at the critical level.
+func (e *Entry) Critical(arg0 interface{}, args ...interface{}) {
+	e.log(CRITICAL, arg0, args...)
+}
+
+func (e *Entry) log(lvl Level, arg0 interface{}, args ...interface{}) {
+	if !e.logger.IsEnabledFor(lvl) {
+		return
+	}
+
+	var msg string
+	switch first := arg0.(type) {
+	case string:
+		msg = first
+		if len(args) > 0 {
+			msg = fmt.Sprintf(first, args...)
+		}
+	case func() string:
+		msg = first()
+	default:
+		msg = fmt.Sprintf(fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
+	}
+
+	// the caller of Debug, Info ...
+	pc, _, lineno, ok := runtime.Caller(2)
+	src := ""
+	if ok {
+		src = fmt.Sprintf("%s:%d", runtime.FuncForPC(pc).Name(), lineno)
+	}
+
+	clientIp, logId, url := e.glValues()
+	rec := &LogRecord{
+		Level:   lvl,
+		Created: time.Now(),
+		Source:  src,
+		Message: msg,
+		Tag:     e.tag,
+		Ip:      clientIp,
+		LogId:   logId,
+		Url:     url,
+		Fields:  e.Fields(),
+	}
+	if !e.logger.sample(rec) {
+		return
+	}
+	ov := e.logger.override(rec)
+	excl := e.logger.exclusive(rec, ov)
+	for _, filt := range e.logger {
+		if ov.skip(filt, lvl) || !filt.routes(rec, excl) {
+			continue
+		}
+		filt.LogWrite(rec)
+	}
+}
+
+// glValues returns the values of the context of e, those missing are read
+// from gl.
+func (e *Entry) glValues() (clientIp, logId, url string) {
+	v := valuesOf(e.ctx)
+	if v == nil {
+		return batchGetGl()
+	}
+	clientIp, logId, url = v.clientIp, v.logId, v.url
+	if clientIp == "" || logId == "" || url == "" {
+		glIp, glLogId, glUrl := batchGetGl()
+		if clientIp == "" {
+			clientIp = glIp
+		}
+		if logId == "" {
+			logId = glLogId
+		}
+		if url == "" {
+			url = glUrl
+		}
+	}
+	return
+}
+
+func toFields(kv []interface{}) []Field {
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		var value interface{}
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		fields = append(fields, Field{Key: key, Value: value})
+	}
+	return fields
+}
+
+// formatFields writes the fields as a json object, the way the SESSION logs
+// appended them to the message.
+func formatFields(out *bytes.Buffer, fields []Field) {
+	out.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		writeJSONField(out, f.Key, f.Value)
+	}
+	out.WriteByte('}')
+}
+
+// formatJSON returns rec as a line of json.
+func formatJSON(rec *LogRecord) string {
+	out := bytes.NewBuffer(make([]byte, 0, 256))
+	out.WriteByte('{')
+	writeJSONField(out, "time", rec.Created.Format("2006-01-02T15:04:05.000Z07:00"))
+	out.WriteByte(',')
+	writeJSONField(out, "level", levelStrings[rec.Level])
+	for _, kv := range [...][2]string{
+		{"tag", rec.Tag},
+		{"logId", rec.LogId},
+		{"clientIp", rec.Ip},
+		{"url", rec.Url},
+		{"source", rec.Source},
+	} {
+		if kv[1] != "" {
+			out.WriteByte(',')
+			writeJSONField(out, kv[0], kv[1])
+		}
+	}
+	out.WriteByte(',')
+	writeJSONField(out, "msg", rec.Message)
+	for _, f := range rec.Fields {
+		key := f.Key
+		if reservedKeys[key] {
+			key = "fields." + key
+		}
+		out.WriteByte(',')
+		writeJSONField(out, key, f.Value)
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
+
+func writeJSONField(out *bytes.Buffer, key string, value interface{}) {
+	k, _ := marshal(key)
+	out.Write(k)
+	out.WriteByte(':')
+
+	if err, ok := value.(error); ok && err != nil {
+		value = err.Error()
+	}
+	v, err := marshal(value)
+	if err != nil {
+		v, _ = marshal(fmt.Sprint(value))
+	}
+	out.Write(v)
+}
+
+// marshal is json.Marshal without escaping &, < and >, like utls.Marshal.
+func marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(b.Bytes(), "\n"), nil
+}
@@ -0,0 +1,92 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// recordWriter keeps the records written.
+type recordWriter struct {
+	recs []*LogRecord
+}
+
+func (w *recordWriter) LogWrite(rec *LogRecord) { w.recs = append(w.recs, rec) }
+func (w *recordWriter) Close()                  {}
+
+func TestWith(t *testing.T) {
+	w := &recordWriter{}
+	l := make(Logger)
+	l.AddFilter("rec", INFO, w)
+
+	e := l.With("uid", 10, "err", errors.New("fail"))
+	e.Tag("SESSION").Info("login %s", "ok")
+	e.With("cost", 5).Warn("slow")
+	e.Debug("not logged")
+
+	if len(w.recs) != 2 {
+		t.Fatalf("%d records, want 2", len(w.recs))
+	}
+	rec := w.recs[0]
+	if rec.Message != "login ok" || rec.Tag != "SESSION" || len(rec.Fields) != 2 {
+		t.Errorf("record = %+v", rec)
+	}
+	if !strings.Contains(rec.Source, "TestWith") {
+		t.Errorf("source = %q", rec.Source)
+	}
+	if n := len(w.recs[1].Fields); n != 3 || len(e.Fields()) != 2 {
+		t.Errorf("fields = %d, of e %d", n, len(e.Fields()))
+	}
+}
+
+func TestFormatFields(t *testing.T) {
+	rec := newLogRecord(WARNING, "source", "/api/login")
+	rec.Tag = "SESSION"
+	rec.LogId = "abc"
+	rec.Url = "/api/login"
+	rec.Fields = toFields([]interface{}{"code", 0, "ret", "a<b", "msg", "dup", "err", errors.New("fail"), "alone"})
+
+	cache := formatCacheType{}
+	got := FormatLogRecord(&cache, "[%L] %M", rec)
+	want := `[WARN] /api/login {"code":0,"ret":"a<b","msg":"dup","err":"fail","alone":null}` + "\n"
+	if got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	if got := FormatLogRecord(&cache, "%M %U %F", rec); got != `/api/login /api/login {"code":0,"ret":"a<b","msg":"dup","err":"fail","alone":null}`+"\n" {
+		t.Errorf("text = %q", got)
+	}
+
+	line := FormatLogRecord(&cache, FORMAT_JSON, rec)
+	if !strings.HasSuffix(line, "}\n") || strings.Count(line, "\n") != 1 {
+		t.Fatalf("json = %q", line)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]interface{}{
+		"time":       "2009-02-13T23:31:30.123Z",
+		"level":      "WARN",
+		"tag":        "SESSION",
+		"logId":      "abc",
+		"url":        "/api/login",
+		"source":     "source",
+		"msg":        "/api/login",
+		"fields.msg": "dup",
+		"code":       float64(0),
+		"err":        "fail",
+	} {
+		if m[k] != v {
+			t.Errorf("json %s = %v, want %v", k, m[k], v)
+		}
+	}
+	if _, ok := m["clientIp"]; ok {
+		t.Error("json has an empty clientIp")
+	}
+}
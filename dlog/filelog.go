@@ -16,8 +16,8 @@ import (
 
 // This log writer sends output to a file
 type FileLogWriter struct {
-	rec  chan *LogRecord
-	stop chan bool
+	queue *logQueue
+	stop  chan bool
 	rot  chan bool
 
 	// The opened file
@@ -52,29 +52,23 @@ type FileLogWriter struct {
 	rotate         bool
 	ScribeCategory string
 
+	// Retention of the old logfiles, see retention.go
+	maxbackups int
+	maxage     time.Duration
+	compress   bool
+	clean      chan bool
+
 	formatCache formatCacheType
 }
 
-// This is the FileLogWriter's output method
+// This is the FileLogWriter's output method, a full buffer is handled by
+// the policy of SetPolicy.
 func (w *FileLogWriter) LogWrite(rec *LogRecord) {
 	select {
-	case w.rec <- rec:
 	case <-w.stop:
 		log.Println(fmt.Sprintf("write on closed logger:%v", rec))
 	default:
-		/**
-		time.After需要新启goroutine计时，消耗很大。
-		只有当确认当前日志channel block了，才需要通过time.After计算等待时间。
-		这样应该可以解决并发日志过多时的报警问题。
-		**/
-		select {
-		case w.rec <- rec:
-		case <-w.stop:
-			log.Println(fmt.Sprintf("write on closed logger:%v", rec))
-		case <-time.After(2 * time.Millisecond):
-			//add "fatal " prefix in stderr log to trigger sms alert
-			fmt.Fprintf(os.Stderr, "fatal file log channel blocked!%v\n", rec)
-		}
+		w.queue.push(rec, w.stop)
 	}
 }
 
@@ -83,6 +77,7 @@ func (w *FileLogWriter) Close() {
 		w.fileCloseLock.Lock()
 		defer w.fileCloseLock.Unlock()
 		close(w.stop)
+		w.queue.close()
 		w.file.Sync() //FIXME race with line 85
 	})
 }
@@ -98,21 +93,25 @@ func (w *FileLogWriter) Close() {
 //   [%D %T] [%L] (%S) %M
 func NewFileLogWriter(fileName string, rotate bool) *FileLogWriter {
 	w := &FileLogWriter{
-		rec:         make(chan *LogRecord, LogBufferLength),
+		queue:       newLogQueue("file:"+fileName, PolicyBlockTimeout, 2*time.Millisecond),
 		stop:        make(chan bool),
 		rot:         make(chan bool),
+		clean:       make(chan bool, 1),
 		filename:    fileName,
 		format:      "[%D %T] [%L] (%S) %M",
 		rotate:      rotate,
 		formatCache: formatCacheType{},
 	}
+	w.queue.alert = "fatal file log channel blocked!"
 
 	// open the file for the first time
 	if err := w.intRotate(); err != nil {
 		fmt.Fprintf(os.Stderr, "FileLogWriter(%q): %s\n", w.filename, err)
+		w.queue.close()
 		return nil
 	}
 
+	go w.cleaner()
 	go func() {
 		defer func() {
 			if w.file != nil {
@@ -123,6 +122,9 @@ func NewFileLogWriter(fileName string, rotate bool) *FileLogWriter {
 			}
 		}()
 
+		// the old logfiles of the last run are cleaned on the first record,
+		// after the retention is set
+		cleaned := false
 		for {
 			select {
 			case <-w.stop:
@@ -134,10 +136,14 @@ func NewFileLogWriter(fileName string, rotate bool) *FileLogWriter {
 					//          just print err msg and return will cause all goroutine hanged in printing log.
 					panic(fmt.Sprintf("FileLogWriter(%q): %s\n", w.filename, err))
 				}
-			case rec, ok := <-w.rec:
+			case rec, ok := <-w.queue.c:
 				if !ok {
 					return
 				}
+				if !cleaned {
+					cleaned = true
+					w.cleanup()
+				}
 				now := &rec.Created
 				if (w.maxlines > 0 && w.maxlines_curlines >= w.maxlines) ||
 					(w.maxsize > 0 && w.maxsize_cursize >= w.maxsize) ||
@@ -159,6 +165,8 @@ func NewFileLogWriter(fileName string, rotate bool) *FileLogWriter {
 					panic(fmt.Sprintf("FileLogWriter(%q): %s\n", w.filename, err))
 				}
 
+				w.queue.done()
+
 				// Update the counts
 				w.maxlines_curlines++
 				w.maxsize_cursize += n
@@ -247,6 +255,7 @@ func (w *FileLogWriter) intRotateTime(now *time.Time) error {
 			if err != nil {
 				return fmt.Errorf("Rotate: %s\n", err)
 			}
+			w.cleanup()
 		}
 	}
 
@@ -305,6 +314,14 @@ func (w *FileLogWriter) SetFormat(format string) *FileLogWriter {
 	return w
 }
 
+// Set the policy of a full buffer (chainable), default PolicyBlockTimeout of
+// 2ms. timeout is the wait of PolicyBlockTimeout, 0 keeps it. It may be
+// called at any time.
+func (w *FileLogWriter) SetPolicy(policy Policy, timeout time.Duration) *FileLogWriter {
+	w.queue.setPolicy(policy, timeout)
+	return w
+}
+
 // Set the logfile header and footer (chainable).  Must be called before the first log
 // message is written.  These are formatted similar to the FormatLogRecord (e.g.
 // you can use %D and %T in your header/footer for date and time).
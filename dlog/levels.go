@@ -0,0 +1,229 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LevelOverride is the level of the records of a tag, or of the call sites
+// of a package and its subpackages, in place of the levels of the filters.
+// The filters at the lowest level of a logger log the records at Level and
+// above, the others keep their levels above it, so an override of DEBUG does
+// not write debug logs to the error file.
+type LevelOverride struct {
+	Tag     string
+	Package string
+	Level   Level
+	// Expire is when the override is removed, zero keeps it
+	Expire time.Time
+}
+
+type levelOverrides struct {
+	lock     sync.RWMutex
+	tags     map[string]*overrideEntry
+	packages map[string]*overrideEntry
+
+	// the lowest level of the overrides, -1 without overrides
+	min int32
+}
+
+type overrideEntry struct {
+	LevelOverride
+	timer *time.Timer
+}
+
+var overrides = &levelOverrides{
+	tags:     make(map[string]*overrideEntry),
+	packages: make(map[string]*overrideEntry),
+	min:      -1,
+}
+
+// SetTagLevel sets the level of the records of tag, a ttl > 0 removes it
+// after ttl.
+func SetTagLevel(tag string, lvl Level, ttl time.Duration) {
+	overrides.set(overrides.tags, LevelOverride{Tag: tag, Level: lvl}, ttl)
+}
+
+// SetPackageLevel sets the level of the records of the call sites in the
+// package of path pkg, e.g. "github.com/Xxianglei/gd/net/dhttp", and its
+// subpackages. A ttl > 0 removes it after ttl.
+func SetPackageLevel(pkg string, lvl Level, ttl time.Duration) {
+	pkg = strings.TrimSuffix(pkg, "/")
+	overrides.set(overrides.packages, LevelOverride{Package: pkg, Level: lvl}, ttl)
+}
+
+// ResetTagLevel removes the level of tag, it reports false if there is none.
+func ResetTagLevel(tag string) bool {
+	return overrides.remove(overrides.tags, tag, nil)
+}
+
+// ResetPackageLevel removes the level of pkg, it reports false if there is
+// none.
+func ResetPackageLevel(pkg string) bool {
+	return overrides.remove(overrides.packages, strings.TrimSuffix(pkg, "/"), nil)
+}
+
+// ResetLevels removes the levels of every tag and package.
+func ResetLevels() {
+	o := overrides
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	for _, m := range []map[string]*overrideEntry{o.tags, o.packages} {
+		for k, e := range m {
+			if e.timer != nil {
+				e.timer.Stop()
+			}
+			delete(m, k)
+		}
+	}
+	o.updateMin()
+}
+
+// LevelOverrides returns the levels of the tags, then of the packages.
+func LevelOverrides() []LevelOverride {
+	o := overrides
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	var ret []LevelOverride
+	for _, m := range []map[string]*overrideEntry{o.tags, o.packages} {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ret = append(ret, m[k].LevelOverride)
+		}
+	}
+	return ret
+}
+
+func (o *levelOverrides) set(m map[string]*overrideEntry, lo LevelOverride, ttl time.Duration) {
+	key := lo.Tag + lo.Package
+	e := &overrideEntry{LevelOverride: lo}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if old, ok := m[key]; ok && old.timer != nil {
+		old.timer.Stop()
+	}
+	if ttl > 0 {
+		e.Expire = time.Now().Add(ttl)
+		e.timer = time.AfterFunc(ttl, func() {
+			if o.remove(m, key, e) {
+				Global.LogWithTag("LOG_LEVEL", INFO, "dlog", "level "+lo.Level.String()+" of "+key+" expired")
+			}
+		})
+	}
+	m[key] = e
+	o.updateMin()
+}
+
+// remove removes the override of key, or only e if it is not nil.
+func (o *levelOverrides) remove(m map[string]*overrideEntry, key string, e *overrideEntry) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	old, ok := m[key]
+	if !ok || (e != nil && old != e) {
+		return false
+	}
+	if old.timer != nil {
+		old.timer.Stop()
+	}
+	delete(m, key)
+	o.updateMin()
+	return true
+}
+
+// updateMin is called with o locked.
+func (o *levelOverrides) updateMin() {
+	min := int32(-1)
+	for _, m := range []map[string]*overrideEntry{o.tags, o.packages} {
+		for _, e := range m {
+			if min < 0 || int32(e.Level) < min {
+				min = int32(e.Level)
+			}
+		}
+	}
+	atomic.StoreInt32(&o.min, min)
+}
+
+// overridden reports whether an override may log records of lvl, which the
+// filters skip.
+func overridden(lvl Level) bool {
+	min := atomic.LoadInt32(&overrides.min)
+	return min >= 0 && int32(lvl) >= min
+}
+
+// overrideLevel is the level of an override of a record, with the lowest
+// level of the filters of the logger.
+type overrideLevel struct {
+	level Level
+	base  Level
+}
+
+// override returns the level of the override of rec, nil if there is none.
+// The override of the tag comes first, then of the longest package.
+func (log Logger) override(rec *LogRecord) *overrideLevel {
+	if atomic.LoadInt32(&overrides.min) < 0 {
+		return nil
+	}
+	o := overrides
+	o.lock.RLock()
+	e, ok := o.tags[rec.Tag]
+	if !ok && len(o.packages) > 0 {
+		for pkg := sourcePackage(rec.Source); pkg != "" && !ok; pkg = parentPackage(pkg) {
+			e, ok = o.packages[pkg]
+		}
+	}
+	o.lock.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	ov := &overrideLevel{level: e.Level, base: -1}
+	for _, filt := range log {
+		if lvl := filt.level(); ov.base < 0 || lvl < ov.base {
+			ov.base = lvl
+		}
+	}
+	return ov
+}
+
+// skip reports whether filt skips a record of lvl, ov may be nil.
+func (ov *overrideLevel) skip(filt *Filter, lvl Level) bool {
+	if ov == nil {
+		return lvl < filt.level()
+	}
+	if filt.level() <= ov.base {
+		return lvl < ov.level
+	}
+	return lvl < filt.level() || lvl < ov.level
+}
+
+// sourcePackage returns the package path of a source of LogRecord, e.g.
+// "github.com/Xxianglei/gd/dlog" of "github.com/Xxianglei/gd/dlog.(*T).F:12".
+func sourcePackage(src string) string {
+	slash := strings.LastIndexByte(src, '/')
+	dot := strings.IndexByte(src[slash+1:], '.')
+	if dot < 0 {
+		return ""
+	}
+	return src[:slash+1+dot]
+}
+
+func parentPackage(pkg string) string {
+	i := strings.LastIndexByte(pkg, '/')
+	if i < 0 {
+		return ""
+	}
+	return pkg[:i]
+}
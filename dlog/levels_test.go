@@ -0,0 +1,84 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelOverride(t *testing.T) {
+	defer ResetLevels()
+
+	main, errs := &recordWriter{}, &recordWriter{}
+	l := make(Logger)
+	l.AddFilter("main", INFO, main)
+	l.AddFilter("err", WARNING, errs)
+
+	SetTagLevel("SESSION", DEBUG, 0)
+	SetTagLevel("NOISY", ERROR, 0)
+	SetPackageLevel("github.com/Xxianglei/gd/net/", DEBUG, 0)
+	SetPackageLevel("github.com/Xxianglei/gd/net/dhttp", WARNING, 0)
+
+	l.LogWithTag("SESSION", DEBUG, "src", "session debug")
+	l.LogWithTag("NOISY", WARNING, "src", "noisy warn")
+	l.LogWithTag("NOISY", ERROR, "src", "noisy error")
+	l.Log(DEBUG, "github.com/Xxianglei/gd/net/dgrpc.(*T).F:12", "dgrpc debug")
+	l.Log(INFO, "github.com/Xxianglei/gd/net/dhttp.F:12", "dhttp info")
+	l.Log(WARNING, "github.com/Xxianglei/gd/net/dhttp.F:12", "dhttp warn")
+	l.Log(DEBUG, "github.com/Xxianglei/gd/dlog.F:12", "dlog debug")
+
+	msgs := func(w *recordWriter) string {
+		var ms []string
+		for _, rec := range w.recs {
+			ms = append(ms, rec.Message)
+		}
+		return strings.Join(ms, "|")
+	}
+	if got, want := msgs(main), "session debug|noisy error|dgrpc debug|dhttp warn"; got != want {
+		t.Errorf("main = %q, want %q", got, want)
+	}
+	if got, want := msgs(errs), "noisy error|dhttp warn"; got != want {
+		t.Errorf("err = %q, want %q", got, want)
+	}
+	if ovs := LevelOverrides(); len(ovs) != 4 || ovs[0].Tag != "NOISY" || ovs[2].Package != "github.com/Xxianglei/gd/net" {
+		t.Errorf("overrides = %+v", ovs)
+	}
+	if !l.IsEnabledFor(DEBUG) {
+		t.Error("debug disabled with overrides of DEBUG")
+	}
+
+	// the ttl reverts it
+	ResetLevels()
+	SetTagLevel("SESSION", DEBUG, 10*time.Millisecond)
+	if !ResetTagLevel("SESSION") || ResetTagLevel("SESSION") {
+		t.Error("reset SESSION")
+	}
+	SetTagLevel("SESSION", DEBUG, 10*time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for len(LevelOverrides()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if ovs := LevelOverrides(); len(ovs) != 0 {
+		t.Fatalf("overrides = %+v after the ttl", ovs)
+	}
+	if l.IsEnabledFor(DEBUG) {
+		t.Error("debug enabled without overrides")
+	}
+}
+
+func TestSourcePackage(t *testing.T) {
+	for src, want := range map[string]string{
+		"github.com/Xxianglei/gd/dlog.(*T).F:12": "github.com/Xxianglei/gd/dlog",
+		"main.main:3":                            "main",
+		"src":                                    "",
+	} {
+		if got := sourcePackage(src); got != want {
+			t.Errorf("sourcePackage(%q) = %q, want %q", src, got, want)
+		}
+	}
+}
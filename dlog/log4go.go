@@ -54,6 +54,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -68,7 +69,7 @@ const (
 /****** Constants ******/
 
 // These are the integer logging levels used by the logger
-type Level int
+type Level int32
 
 const (
 	FINEST Level = iota
@@ -93,6 +94,29 @@ func (l Level) String() string {
 	return levelStrings[int(l)]
 }
 
+// ParseLevel converts a level name of the xml config, such as "DEBUG", to a Level.
+func ParseLevel(s string) (Level, bool) {
+	switch s {
+	case "FINEST":
+		return FINEST, true
+	case "FINE":
+		return FINE, true
+	case "DEBUG":
+		return DEBUG, true
+	case "TRACE":
+		return TRACE, true
+	case "INFO":
+		return INFO, true
+	case "WARNING":
+		return WARNING, true
+	case "ERROR":
+		return ERROR, true
+	case "CRITICAL":
+		return CRITICAL, true
+	}
+	return INFO, false
+}
+
 /****** Variables ******/
 var (
 	// LogBufferLength specifies how many log messages a particular log4go
@@ -113,6 +137,8 @@ type LogRecord struct {
 	Ip    string // clientIp
 	LogId string // logId
 	Url   string // url
+	// Fields of With
+	Fields []Field
 }
 
 /****** LogWriter ******/
@@ -132,8 +158,21 @@ type LogWriter interface {
 // A Filter represents the log level below which no log records are written to
 // the associated LogWriter.
 type Filter struct {
+	// Level is read and written atomically once the filter is logged to, see
+	// SetFilterLevel
 	Level Level
 	LogWriter
+	// Route limits the records of the filter, nil takes every record without
+	// an exclusive route
+	Route *Route
+}
+
+func (filt *Filter) level() Level {
+	return Level(atomic.LoadInt32((*int32)(&filt.Level)))
+}
+
+func (filt *Filter) setLevel(lvl Level) {
+	atomic.StoreInt32((*int32)(&filt.Level), int32(lvl))
 }
 
 // A Logger represents a collection of Filters through which log messages are
@@ -155,7 +194,7 @@ func NewLogger() Logger {
 func NewConsoleLogger(lvl Level) Logger {
 	os.Stderr.WriteString("warning: use of deprecated NewConsoleLogger\n")
 	return Logger{
-		"stdout": &Filter{lvl, NewConsoleLogWriter()},
+		"stdout": &Filter{Level: lvl, LogWriter: NewConsoleLogWriter()},
 	}
 }
 
@@ -163,7 +202,7 @@ func NewConsoleLogger(lvl Level) Logger {
 // or above lvl to standard output.
 func NewDefaultLogger(lvl Level) Logger {
 	return Logger{
-		"stdout": &Filter{lvl, NewConsoleLogWriter()},
+		"stdout": &Filter{Level: lvl, LogWriter: NewConsoleLogWriter()},
 	}
 }
 
@@ -183,7 +222,7 @@ func (log Logger) Close() {
 // higher.  This function should not be called from multiple goroutines.
 // Returns the logger for chaining.
 func (log Logger) AddFilter(name string, lvl Level, writer LogWriter) Logger {
-	log[name] = &Filter{lvl, writer}
+	log[name] = &Filter{Level: lvl, LogWriter: writer}
 	return log
 }
 
@@ -194,12 +233,12 @@ func (log Logger) intLogf(lvl Level, format string, args ...interface{}) {
 
 	// Determine if any logging will be done
 	for _, filt := range log {
-		if lvl >= filt.Level {
+		if lvl >= filt.level() {
 			skip = false
 			break
 		}
 	}
-	if skip {
+	if skip && !overridden(lvl) {
 		return
 	}
 
@@ -223,10 +262,16 @@ func (log Logger) intLogf(lvl Level, format string, args ...interface{}) {
 		Message: msg,
 	}
 
+	if !log.sample(rec) {
+		return
+	}
+
+	ov := log.override(rec)
+	excl := log.exclusive(rec, ov)
 	found := false
 	// Dispatch the logs
 	for _, filt := range log {
-		if lvl < filt.Level {
+		if ov.skip(filt, lvl) || !filt.routes(rec, excl) {
 			continue
 		}
 
@@ -241,17 +286,17 @@ func (log Logger) intLogf(lvl Level, format string, args ...interface{}) {
 	}
 }
 
-func (log Logger) intLogfTag(tag, clientIp, logId string, lvl Level, format string, args ...interface{}) {
+func (log Logger) intLogfTag(tag, clientIp, logId, url string, lvl Level, format string, args ...interface{}) {
 	skip := true
 
 	// Determine if any logging will be done
 	for _, filt := range log {
-		if lvl >= filt.Level {
+		if lvl >= filt.level() {
 			skip = false
 			break
 		}
 	}
-	if skip {
+	if skip && !overridden(lvl) {
 		return
 	}
 
@@ -276,12 +321,19 @@ func (log Logger) intLogfTag(tag, clientIp, logId string, lvl Level, format stri
 		Tag:     tag,
 		Ip:      clientIp,
 		LogId:   logId,
+		Url:     url,
 	}
 
+	if !log.sample(rec) {
+		return
+	}
+
+	ov := log.override(rec)
+	excl := log.exclusive(rec, ov)
 	found := false
 	// Dispatch the logs
 	for _, filt := range log {
-		if lvl < filt.Level {
+		if ov.skip(filt, lvl) || !filt.routes(rec, excl) {
 			continue
 		}
 
@@ -301,12 +353,12 @@ func (log Logger) IntLogfTagUrl(tag, clientIp, logId, url string, lvl Level, for
 
 	// Determine if any logging will be done
 	for _, filt := range log {
-		if lvl >= filt.Level {
+		if lvl >= filt.level() {
 			skip = false
 			break
 		}
 	}
-	if skip {
+	if skip && !overridden(lvl) {
 		return
 	}
 
@@ -334,10 +386,16 @@ func (log Logger) IntLogfTagUrl(tag, clientIp, logId, url string, lvl Level, for
 		Url:     url,
 	}
 
+	if !log.sample(rec) {
+		return
+	}
+
+	ov := log.override(rec)
+	excl := log.exclusive(rec, ov)
 	found := false
 	// Dispatch the logs
 	for _, filt := range log {
-		if lvl < filt.Level {
+		if ov.skip(filt, lvl) || !filt.routes(rec, excl) {
 			continue
 		}
 
@@ -358,12 +416,12 @@ func (log Logger) intLogc(lvl Level, closure func() string) {
 
 	// Determine if any logging will be done
 	for _, filter := range log {
-		if lvl >= filter.Level {
+		if lvl >= filter.level() {
 			skip = false
 			break
 		}
 	}
-	if skip {
+	if skip && !overridden(lvl) {
 		return
 	}
 
@@ -382,10 +440,16 @@ func (log Logger) intLogc(lvl Level, closure func() string) {
 		Message: closure(),
 	}
 
+	if !log.sample(rec) {
+		return
+	}
+
+	ov := log.override(rec)
+	excl := log.exclusive(rec, ov)
 	found := false
 	// Dispatch the logs
 	for _, filter := range log {
-		if lvl < filter.Level {
+		if ov.skip(filter, lvl) || !filter.routes(rec, excl) {
 			continue
 		}
 
@@ -400,17 +464,17 @@ func (log Logger) intLogc(lvl Level, closure func() string) {
 	}
 }
 
-func (log Logger) intLogcTag(tag, clientIp, logId string, lvl Level, closure func() string) {
+func (log Logger) intLogcTag(tag, clientIp, logId, url string, lvl Level, closure func() string) {
 	skip := true
 
 	// Determine if any logging will be done
 	for _, filter := range log {
-		if lvl >= filter.Level {
+		if lvl >= filter.level() {
 			skip = false
 			break
 		}
 	}
-	if skip {
+	if skip && !overridden(lvl) {
 		return
 	}
 
@@ -430,12 +494,19 @@ func (log Logger) intLogcTag(tag, clientIp, logId string, lvl Level, closure fun
 		Tag:     tag,
 		Ip:      clientIp,
 		LogId:   logId,
+		Url:     url,
+	}
+
+	if !log.sample(rec) {
+		return
 	}
 
+	ov := log.override(rec)
+	excl := log.exclusive(rec, ov)
 	found := false
 	// Dispatch the logs
 	for _, filter := range log {
-		if lvl < filter.Level {
+		if ov.skip(filter, lvl) || !filter.routes(rec, excl) {
 			continue
 		}
 
@@ -456,12 +527,12 @@ func (log Logger) Log(lvl Level, source, message string) {
 
 	// Determine if any logging will be done
 	for _, filter := range log {
-		if lvl >= filter.Level {
+		if lvl >= filter.level() {
 			skip = false
 			break
 		}
 	}
-	if skip {
+	if skip && !overridden(lvl) {
 		return
 	}
 
@@ -473,9 +544,15 @@ func (log Logger) Log(lvl Level, source, message string) {
 		Message: message,
 	}
 
+	if !log.sample(rec) {
+		return
+	}
+
+	ov := log.override(rec)
+	excl := log.exclusive(rec, ov)
 	// Dispatch the logs
 	for _, filter := range log {
-		if lvl < filter.Level {
+		if ov.skip(filter, lvl) || !filter.routes(rec, excl) {
 			continue
 		}
 		filter.LogWrite(rec)
@@ -488,12 +565,12 @@ func (log Logger) LogWithTag(tag string, lvl Level, source, message string) {
 
 	// Determine if any logging will be done
 	for _, filter := range log {
-		if lvl >= filter.Level {
+		if lvl >= filter.level() {
 			skip = false
 			break
 		}
 	}
-	if skip {
+	if skip && !overridden(lvl) {
 		return
 	}
 
@@ -506,9 +583,15 @@ func (log Logger) LogWithTag(tag string, lvl Level, source, message string) {
 		Tag:     tag,
 	}
 
+	if !log.sample(rec) {
+		return
+	}
+
+	ov := log.override(rec)
+	excl := log.exclusive(rec, ov)
 	// Dispatch the logs
 	for _, filter := range log {
-		if lvl < filter.Level {
+		if ov.skip(filter, lvl) || !filter.routes(rec, excl) {
 			continue
 		}
 		filter.LogWrite(rec)
@@ -530,12 +613,12 @@ func (log Logger) Logc(lvl Level, closure func() string) {
 func (log Logger) IsEnabledFor(lvl Level) bool {
 	e := false
 	for _, filter := range log {
-		if lvl >= filter.Level {
+		if lvl >= filter.level() {
 			e = true
 			break
 		}
 	}
-	return e
+	return e || overridden(lvl)
 }
 
 // Finest logs a message at the finest log level.
@@ -0,0 +1,320 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// records kept while the endpoint is down, the oldest are dropped
+	DefaultNetSpool = 10000
+	// facility local0 of syslog
+	DefaultSyslogFacility = 16
+)
+
+var (
+	// NetDialTimeout and NetWriteTimeout bound a connect and a write of a
+	// NetLogWriter, so a slow endpoint does not stop the writer.
+	NetDialTimeout  = time.Second
+	NetWriteTimeout = time.Second
+	// NetCloseTimeout bounds the last send of Close.
+	NetCloseTimeout = time.Second
+)
+
+// NetLogWriter sends records to a tcp, udp or unix endpoint. It connects
+// when there is a record to send and reconnects with an exponential backoff,
+// keeping the records in a bounded spool meanwhile. LogWrite does not block
+// by default, the records it cannot keep are counted by Dropped.
+type NetLogWriter struct {
+	network string
+	addr    string
+
+	queue *logQueue
+	stop  chan bool
+	done  chan bool
+
+	closeOnce sync.Once
+
+	format      string
+	formatCache formatCacheType
+
+	// syslog framing of RFC5424
+	syslog   bool
+	facility int
+	appName  string
+	hostname string
+
+	spool      [][]byte
+	spoolMax   int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	backoff    time.Duration
+
+	conn net.Conn
+	down bool // reported to stderr until reconnected
+}
+
+// NewNetLogWriter creates a NetLogWriter of network tcp, udp, unix or
+// unixgram. The records are json lines, see SetFormat and SetSyslog.
+func NewNetLogWriter(network, addr string) *NetLogWriter {
+	hostname, _ := os.Hostname()
+	w := &NetLogWriter{
+		network:     network,
+		addr:        addr,
+		queue:       newLogQueue("net:"+addr, PolicyDropNewest, 2*time.Millisecond),
+		stop:        make(chan bool),
+		done:        make(chan bool),
+		format:      FORMAT_JSON,
+		formatCache: formatCacheType{},
+		facility:    DefaultSyslogFacility,
+		appName:     filepath.Base(os.Args[0]),
+		hostname:    hostname,
+		spoolMax:    DefaultNetSpool,
+		minBackoff:  100 * time.Millisecond,
+		maxBackoff:  30 * time.Second,
+	}
+	go w.run()
+	return w
+}
+
+// Set the format of the records (chainable), default FORMAT_JSON. Must be
+// called before the first log message is written.
+func (w *NetLogWriter) SetFormat(format string) *NetLogWriter {
+	w.format = format
+	return w
+}
+
+// Set the syslog framing of RFC5424 (chainable), the records are the
+// messages of syslog lines of facility and appName. Must be called before
+// the first log message is written.
+func (w *NetLogWriter) SetSyslog(facility int, appName string) *NetLogWriter {
+	w.syslog = true
+	w.facility = facility
+	if appName != "" {
+		w.appName = appName
+	}
+	return w
+}
+
+// Set the number of records kept while the endpoint is down (chainable),
+// default DefaultNetSpool. Must be called before the first log message is
+// written.
+func (w *NetLogWriter) SetSpool(records int) *NetLogWriter {
+	if records > 0 {
+		w.spoolMax = records
+	}
+	return w
+}
+
+// Set the backoff between connects (chainable), doubled from min to max.
+// Must be called before the first log message is written.
+func (w *NetLogWriter) SetBackoff(min, max time.Duration) *NetLogWriter {
+	if min > 0 {
+		w.minBackoff = min
+	}
+	if max >= w.minBackoff {
+		w.maxBackoff = max
+	}
+	return w
+}
+
+// Set the policy of a full buffer (chainable), default PolicyDropNewest.
+// timeout is the wait of PolicyBlockTimeout. It may be called at any time.
+func (w *NetLogWriter) SetPolicy(policy Policy, timeout time.Duration) *NetLogWriter {
+	w.queue.setPolicy(policy, timeout)
+	return w
+}
+
+// Dropped returns the number of records dropped, because the buffer or the
+// spool was full.
+func (w *NetLogWriter) Dropped() int64 {
+	return atomic.LoadInt64(&w.queue.dropped)
+}
+
+// This is the NetLogWriter's output method, a full buffer is handled by the
+// policy of SetPolicy.
+func (w *NetLogWriter) LogWrite(rec *LogRecord) {
+	w.queue.push(rec, w.stop)
+}
+
+// Close sends the spooled records for at most NetCloseTimeout and closes
+// the connection.
+func (w *NetLogWriter) Close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+		w.queue.close()
+	})
+}
+
+// drop drops n records of the spool, they are no longer pending for Flush.
+func (w *NetLogWriter) drop(n int) {
+	w.queue.drop(int64(n))
+	atomic.AddInt64(&w.queue.pending, -int64(n))
+}
+
+func (w *NetLogWriter) run() {
+	defer close(w.done)
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case rec := <-w.queue.c:
+			w.push(rec)
+		case <-retry:
+			retry = nil
+		case <-w.stop:
+			w.shutdown()
+			return
+		}
+		if retry == nil {
+			retry = w.send()
+		}
+	}
+}
+
+func (w *NetLogWriter) shutdown() {
+	deadline := time.Now().Add(NetCloseTimeout)
+drain:
+	for {
+		select {
+		case rec := <-w.queue.c:
+			w.push(rec)
+		default:
+			break drain
+		}
+	}
+	for len(w.spool) > 0 && time.Now().Before(deadline) {
+		if retry := w.send(); retry != nil {
+			select {
+			case <-retry:
+			case <-time.After(time.Until(deadline)):
+			}
+		}
+	}
+	if len(w.spool) > 0 {
+		w.drop(len(w.spool))
+		w.spool = nil
+	}
+	if w.conn != nil {
+		w.conn.Close()
+	}
+}
+
+// push adds rec to the spool, dropping the oldest record if it is full.
+func (w *NetLogWriter) push(rec *LogRecord) {
+	if len(w.spool) >= w.spoolMax {
+		w.spool = w.spool[1:]
+		w.drop(1)
+	}
+	w.spool = append(w.spool, w.frame(rec))
+}
+
+// send writes the spool, it returns the channel of the next connect if the
+// endpoint is down.
+func (w *NetLogWriter) send() <-chan time.Time {
+	if len(w.spool) == 0 {
+		return nil
+	}
+	if w.conn == nil {
+		conn, err := net.DialTimeout(w.network, w.addr, NetDialTimeout)
+		if err != nil {
+			return w.fail(err)
+		}
+		w.conn = conn
+	}
+
+	for len(w.spool) > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(NetWriteTimeout))
+		if _, err := w.conn.Write(w.spool[0]); err != nil {
+			w.conn.Close()
+			w.conn = nil
+			return w.fail(err)
+		}
+		w.spool[0] = nil
+		w.spool = w.spool[1:]
+		w.queue.done()
+	}
+	if w.down {
+		w.down = false
+		fmt.Fprintf(os.Stderr, "NetLogWriter(%q): reconnected, %d records dropped so far\n", w.addr, w.Dropped())
+	}
+	w.backoff = 0
+	return nil
+}
+
+func (w *NetLogWriter) fail(err error) <-chan time.Time {
+	if !w.down {
+		w.down = true
+		fmt.Fprintf(os.Stderr, "NetLogWriter(%q): %s, retry with backoff\n", w.addr, err)
+	}
+	if w.backoff == 0 {
+		w.backoff = w.minBackoff
+	} else if w.backoff *= 2; w.backoff > w.maxBackoff {
+		w.backoff = w.maxBackoff
+	}
+	return time.After(w.backoff)
+}
+
+// frame returns rec as it is written: a line, a syslog message of octet
+// counting on a stream, or a syslog datagram.
+func (w *NetLogWriter) frame(rec *LogRecord) []byte {
+	msg := strings.TrimRight(FormatLogRecord(&w.formatCache, w.format, rec), "\n")
+	if !w.syslog {
+		return []byte(msg + "\n")
+	}
+
+	msgId := "-"
+	if rec.Tag != "" {
+		msgId = strings.Map(func(r rune) rune {
+			if r <= ' ' || r > '~' {
+				return '_'
+			}
+			return r
+		}, rec.Tag)
+		if len(msgId) > 32 {
+			msgId = msgId[:32]
+		}
+	}
+	line := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		w.facility*8+syslogSeverity(rec.Level),
+		rec.Created.Format("2006-01-02T15:04:05.000000Z07:00"),
+		nilValue(w.hostname), nilValue(w.appName), os.Getpid(), msgId, msg)
+	switch w.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return []byte(fmt.Sprintf("%d %s", len(line), line))
+	}
+	return []byte(line)
+}
+
+func syslogSeverity(lvl Level) int {
+	switch lvl {
+	case CRITICAL:
+		return 2
+	case ERROR:
+		return 3
+	case WARNING:
+		return 4
+	case INFO:
+		return 6
+	}
+	return 7
+}
+
+func nilValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.Replace(s, " ", "_", -1)
+}
@@ -0,0 +1,129 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNetLogWriterSyslog(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	w := NewNetLogWriter("tcp", ln.Addr().String()).SetSyslog(1, "app").SetFormat("%M")
+	defer w.Close()
+	rec := newLogRecord(ERROR, "source", "disk full")
+	rec.Tag = "DISK ALERT"
+	w.LogWrite(rec)
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	// octet counting: the length, a space and the message
+	r := bufio.NewReader(conn)
+	size, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(size))
+	if err != nil {
+		t.Fatalf("octet count %q", size)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		t.Fatal(err)
+	}
+	want := `^<11>1 2009-02-13T23:31:30\.123456Z \S+ app \d+ DISK_ALERT - disk full$`
+	if !regexp.MustCompile(want).Match(msg) {
+		t.Errorf("syslog = %q, want %s", msg, want)
+	}
+}
+
+func TestNetLogWriterReconnect(t *testing.T) {
+	// a port that is down
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := NewNetLogWriter("tcp", addr).SetSpool(2).SetBackoff(10*time.Millisecond, 20*time.Millisecond)
+	defer w.Close()
+	for i := 0; i < 5; i++ {
+		w.LogWrite(newLogRecord(INFO, "source", "message "+strconv.Itoa(i)))
+	}
+	deadline := time.Now().Add(time.Second)
+	for w.Dropped() != 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if w.Dropped() != 3 {
+		t.Fatalf("dropped = %d, want 3", w.Dropped())
+	}
+
+	// the spool is sent once the endpoint is up
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"message 3", "message 4"} {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(line, `"msg":"`+want+`"`) {
+			t.Errorf("line = %q, want %s", line, want)
+		}
+	}
+}
+
+func TestLoadConfigurationSocketDown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "socket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "log.xml")
+	conf := `<logging><filter enabled="true"><tag>socket</tag><type>socket</type><level>INFO</level>` +
+		`<property name="endpoint">127.0.0.1:1</property><property name="protocol">tcp</property></filter></logging>`
+	if err := ioutil.WriteFile(file, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the filter is skipped instead of a nil writer
+	l := make(Logger)
+	l.LoadConfiguration(file)
+	l.Info("message")
+	if len(l) != 0 {
+		t.Errorf("filters = %v", l)
+	}
+	l.Close()
+}
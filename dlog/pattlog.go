@@ -38,8 +38,11 @@ type formatCacheType struct {
 // %G - tag
 // %I - ip
 // %l - logId
+// %U - url
+// %F - fields of With as a json object, they follow %M if %F is missing
 // Ignores unknown formats
 // Recommended: "[%D %T] [%L] (%S) %M"
+// FORMAT_JSON writes a line of json instead.
 func FormatLogRecord(formatCache *formatCacheType, format string, rec *LogRecord) string {
 	if rec == nil {
 		return "<nil>"
@@ -47,6 +50,10 @@ func FormatLogRecord(formatCache *formatCacheType, format string, rec *LogRecord
 	if len(format) == 0 {
 		return ""
 	}
+	if format == FORMAT_JSON {
+		return formatJSON(rec)
+	}
+	fieldsAfterMessage := len(rec.Fields) > 0 && !strings.Contains(format, "%F")
 
 	out := bytes.NewBuffer(make([]byte, 0, 64))
 	secs := rec.Created.UnixNano() / 1e9
@@ -92,6 +99,16 @@ func FormatLogRecord(formatCache *formatCacheType, format string, rec *LogRecord
 				out.WriteString(slice[len(slice)-1])
 			case 'M':
 				out.WriteString(rec.Message)
+				if fieldsAfterMessage {
+					out.WriteByte(' ')
+					formatFields(out, rec.Fields)
+				}
+			case 'F':
+				if len(rec.Fields) > 0 {
+					formatFields(out, rec.Fields)
+				}
+			case 'U':
+				out.WriteString(rec.Url)
 			case 'c':
 				out.WriteString(strconv.FormatInt(secs, 10))
 			case 'G':
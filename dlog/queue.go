@@ -0,0 +1,212 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy is what LogWrite of an asynchronous writer does when its buffer
+// of LogBufferLength records is full.
+type Policy int
+
+const (
+	// PolicyBlock waits for the writer.
+	PolicyBlock Policy = iota
+	// PolicyDropNewest drops the record written.
+	PolicyDropNewest
+	// PolicyDropOldest drops the oldest buffered record for the new one.
+	PolicyDropOldest
+	// PolicyBlockTimeout waits for the timeout of the writer, then drops
+	// the record written.
+	PolicyBlockTimeout
+)
+
+var policyNames = [...]string{"block", "drop-newest", "drop-oldest", "block-timeout"}
+
+func (p Policy) String() string {
+	if p < 0 || int(p) >= len(policyNames) {
+		return "Policy(" + fmt.Sprint(int(p)) + ")"
+	}
+	return policyNames[p]
+}
+
+// ParsePolicy returns the Policy of a name, e.g. "drop-oldest".
+func ParsePolicy(name string) (Policy, bool) {
+	for i, n := range policyNames {
+		if n == name {
+			return Policy(i), true
+		}
+	}
+	return PolicyBlock, false
+}
+
+// FlushInterval is the interval Flush checks the buffers at.
+var FlushInterval = 5 * time.Millisecond
+
+// logQueue is the buffer of an asynchronous writer, it counts the records
+// buffered and being written for Flush.
+type logQueue struct {
+	name    string
+	c       chan *LogRecord
+	policy  int32 // Policy, changed at any time by setPolicy
+	timeout int64 // time.Duration of PolicyBlockTimeout
+	// written to stderr with the records dropped by a timeout, the "fatal "
+	// prefix triggers the sms alert
+	alert string
+
+	pending int64
+	dropped int64
+}
+
+var (
+	queuesLock sync.Mutex
+	queues     = make(map[*logQueue]bool)
+)
+
+func newLogQueue(name string, policy Policy, timeout time.Duration) *logQueue {
+	q := &logQueue{
+		name:    name,
+		c:       make(chan *LogRecord, LogBufferLength),
+		policy:  int32(policy),
+		timeout: int64(timeout),
+	}
+	queuesLock.Lock()
+	queues[q] = true
+	queuesLock.Unlock()
+	return q
+}
+
+// setPolicy changes the policy at any time, a timeout of 0 keeps it.
+func (q *logQueue) setPolicy(policy Policy, timeout time.Duration) {
+	atomic.StoreInt32(&q.policy, int32(policy))
+	if timeout > 0 {
+		atomic.StoreInt64(&q.timeout, int64(timeout))
+	}
+}
+
+// push buffers rec by the policy of q, it reports false if rec is dropped.
+// A closed stop drops rec.
+func (q *logQueue) push(rec *LogRecord, stop <-chan bool) bool {
+	atomic.AddInt64(&q.pending, 1)
+	select {
+	case q.c <- rec:
+		return true
+	case <-stop:
+		atomic.AddInt64(&q.pending, -1)
+		return false
+	default:
+	}
+
+	switch Policy(atomic.LoadInt32(&q.policy)) {
+	case PolicyBlock:
+		select {
+		case q.c <- rec:
+			return true
+		case <-stop:
+		}
+	case PolicyDropOldest:
+		for i := 0; i < 3; i++ {
+			select {
+			case q.c <- rec:
+				return true
+			case <-q.c:
+				q.done()
+				q.drop(1)
+			}
+		}
+	case PolicyBlockTimeout:
+		//time.After需要新启goroutine计时，只有当确认当前日志channel block了，才需要计算等待时间。
+		t := time.NewTimer(time.Duration(atomic.LoadInt64(&q.timeout)))
+		defer t.Stop()
+		select {
+		case q.c <- rec:
+			return true
+		case <-stop:
+		case <-t.C:
+			if q.alert != "" {
+				fmt.Fprintf(os.Stderr, "%s%v\n", q.alert, rec)
+			}
+		}
+	}
+	atomic.AddInt64(&q.pending, -1)
+	q.drop(1)
+	return false
+}
+
+// done is called once a record of q is written.
+func (q *logQueue) done() {
+	atomic.AddInt64(&q.pending, -1)
+}
+
+// drop counts n records dropped.
+func (q *logQueue) drop(n int64) {
+	atomic.AddInt64(&q.dropped, n)
+	count("dlog_dropped,writer="+q.name, n)
+}
+
+// close removes q from Flush and QueueDepths.
+func (q *logQueue) close() {
+	queuesLock.Lock()
+	delete(queues, q)
+	queuesLock.Unlock()
+}
+
+// Flush waits until the records buffered by the asynchronous writers are
+// written, or ctx is done.
+func Flush(ctx context.Context) error {
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+	for {
+		pending := false
+		queuesLock.Lock()
+		for q := range queues {
+			if atomic.LoadInt64(&q.pending) > 0 {
+				pending = true
+				break
+			}
+		}
+		queuesLock.Unlock()
+		if !pending {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// QueueDepths returns the records buffered by every asynchronous writer,
+// pc reports them as dlog_queue_depth,writer=... The drops are counted by
+// the counter of SetCounter as dlog_dropped,writer=...
+func QueueDepths() map[string]int64 {
+	queuesLock.Lock()
+	defer queuesLock.Unlock()
+	depths := make(map[string]int64, len(queues))
+	for q := range queues {
+		depths["dlog_queue_depth,writer="+q.name] += int64(len(q.c))
+	}
+	return depths
+}
+
+// configure sets the policy of a name and the timeout of a filter of the
+// xml configuration, "" and 0 keep the defaults of the writer.
+func (q *logQueue) configure(policy string, timeout time.Duration) {
+	if p, ok := ParsePolicy(policy); ok {
+		atomic.StoreInt32(&q.policy, int32(p))
+	}
+	if timeout > 0 {
+		atomic.StoreInt64(&q.timeout, int64(timeout))
+	}
+}
@@ -0,0 +1,81 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueuePolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy  Policy
+		pushed  bool
+		dropped int64
+		left    string
+	}{
+		{PolicyDropNewest, false, 1, "first"},
+		{PolicyDropOldest, true, 1, "second"},
+		{PolicyBlockTimeout, false, 1, "first"},
+	} {
+		q := &logQueue{name: "test", c: make(chan *LogRecord, 1), policy: int32(tt.policy), timeout: int64(time.Millisecond)}
+		q.push(newLogRecord(INFO, "source", "first"), nil)
+		if pushed := q.push(newLogRecord(INFO, "source", "second"), nil); pushed != tt.pushed {
+			t.Errorf("%s: pushed = %v, want %v", tt.policy, pushed, tt.pushed)
+		}
+		if q.dropped != tt.dropped || q.pending != 1 {
+			t.Errorf("%s: dropped = %d, pending = %d", tt.policy, q.dropped, q.pending)
+		}
+		if rec := <-q.c; rec.Message != tt.left {
+			t.Errorf("%s: left %q, want %q", tt.policy, rec.Message, tt.left)
+		}
+	}
+
+	// block waits for the writer, or the stop
+	q := &logQueue{name: "test", c: make(chan *LogRecord, 1), policy: int32(PolicyBlock)}
+	q.push(newLogRecord(INFO, "source", "first"), nil)
+	pushed := make(chan bool)
+	go func() {
+		pushed <- q.push(newLogRecord(INFO, "source", "second"), nil)
+	}()
+	<-q.c
+	if !<-pushed {
+		t.Error("block: not pushed")
+	}
+	stop := make(chan bool)
+	close(stop)
+	if q.push(newLogRecord(INFO, "source", "third"), stop) || q.pending != 2 {
+		t.Errorf("block: pushed after stop, pending = %d", q.pending)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	q := newLogQueue("test", PolicyBlock, 0)
+	defer q.close()
+	q.push(newLogRecord(INFO, "source", "message"), nil)
+	if d := QueueDepths()["dlog_queue_depth,writer=test"]; d != 1 {
+		t.Errorf("depth = %d, want 1", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Flush = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	go func() {
+		<-q.c
+		time.Sleep(10 * time.Millisecond)
+		q.done()
+	}()
+	if err := Flush(context.Background()); err != nil {
+		t.Errorf("Flush = %v", err)
+	}
+	if q.pending != 0 {
+		t.Errorf("pending = %d", q.pending)
+	}
+}
@@ -0,0 +1,196 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TAG_RETENTION tags the logs of removed and compressed files.
+const TAG_RETENTION = "LOG_RETENTION"
+
+// suffixes of the files renamed by intRotateTime: .20060102, .2006010215,
+// with .001 ... on collision, and .gz once compressed
+var backupSuffix = regexp.MustCompile(`^\.\d{8}(\d{2})?(\.\d{3})?(\.gz)?$`)
+
+// Set the number of rotated files kept (chainable), the oldest are removed
+// first. 0 keeps all of them.
+func (w *FileLogWriter) SetMaxBackups(maxbackups int) *FileLogWriter {
+	w.maxbackups = maxbackups
+	return w
+}
+
+// Set the age of the rotated files removed (chainable), by their time of
+// modification. 0 keeps all of them.
+func (w *FileLogWriter) SetMaxAge(maxage time.Duration) *FileLogWriter {
+	w.maxage = maxage
+	return w
+}
+
+// Set whether the rotated files are gzipped (chainable), in the background
+// after the rotation.
+func (w *FileLogWriter) SetCompress(compress bool) *FileLogWriter {
+	w.compress = compress
+	return w
+}
+
+// cleanup asks the cleaner to apply the retention, it does not wait.
+func (w *FileLogWriter) cleanup() {
+	if w.maxbackups <= 0 && w.maxage <= 0 && !w.compress {
+		return
+	}
+	select {
+	case w.clean <- true:
+	default:
+		// a cleanup is pending
+	}
+}
+
+// cleaner applies the retention until the writer is closed.
+func (w *FileLogWriter) cleaner() {
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.clean:
+			w.intClean(time.Now())
+		}
+	}
+}
+
+type backupFile struct {
+	name    string
+	modTime time.Time
+}
+
+// backups returns the rotated files of w, the newest first.
+func (w *FileLogWriter) backups() ([]backupFile, error) {
+	dir, base := filepath.Split(w.filename)
+	if dir == "" {
+		dir = "."
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []backupFile
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, base) || !backupSuffix.MatchString(name[len(base):]) {
+			continue
+		}
+		files = append(files, backupFile{name: filepath.Join(dir, name), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].name > files[j].name
+		}
+		return files[i].modTime.After(files[j].modTime)
+	})
+	return files, nil
+}
+
+func (w *FileLogWriter) intClean(now time.Time) {
+	files, err := w.backups()
+	if err != nil {
+		Global.With("file", w.filename, "err", err).Tag(TAG_RETENTION).Warn("list rotated files fail")
+		return
+	}
+
+	var kept []backupFile
+	for i, f := range files {
+		switch {
+		case w.maxbackups > 0 && i >= w.maxbackups:
+			w.remove(f, "maxbackups")
+		case w.maxage > 0 && now.Sub(f.modTime) > w.maxage:
+			w.remove(f, "maxage")
+		default:
+			kept = append(kept, f)
+		}
+	}
+
+	if !w.compress {
+		return
+	}
+	for _, f := range kept {
+		if strings.HasSuffix(f.name, ".gz") {
+			continue
+		}
+		if err := gzipFile(f.name, f.modTime); err != nil {
+			Global.With("file", f.name, "err", err).Tag(TAG_RETENTION).Warn("compress rotated file fail")
+			continue
+		}
+		Global.With("file", f.name).Tag(TAG_RETENTION).Info("compressed rotated file")
+	}
+}
+
+func (w *FileLogWriter) remove(f backupFile, reason string) {
+	if err := os.Remove(f.name); err != nil {
+		Global.With("file", f.name, "err", err).Tag(TAG_RETENTION).Warn("remove rotated file fail")
+		return
+	}
+	Global.With("file", f.name, "reason", reason, "modTime", f.modTime.Format(time.RFC3339)).Tag(TAG_RETENTION).Info("removed rotated file")
+}
+
+// gzipFile replaces name by name.gz of the same time of modification, so
+// the age of the file is kept.
+func gzipFile(name string, modTime time.Time) (err error) {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := name + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Chtimes(tmp, modTime, modTime); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, name+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+// parseMaxAge parses a number of days, or a duration like 36h.
+func parseMaxAge(s string) time.Duration {
+	if days, err := strconv.Atoi(s); err == nil {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	d, _ := time.ParseDuration(s)
+	return d
+}
@@ -0,0 +1,91 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	for name, age := range map[string]time.Duration{
+		"app.log":                time.Minute,
+		"app.log.2020010104":     time.Hour,
+		"app.log.2020010103.001": 2 * time.Hour,
+		"app.log.2020010103":     3 * time.Hour,
+		"app.log.20200101.gz":    4 * time.Hour,
+		"app.log.2020010101":     50 * time.Hour,
+		"app_err.log.2020010100": 100 * time.Hour,
+		"app.log.bak":            100 * time.Hour,
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(name), 0666); err != nil {
+			t.Fatal(err)
+		}
+		mt := now.Add(-age)
+		os.Chtimes(path, mt, mt)
+	}
+
+	w := &FileLogWriter{filename: filepath.Join(dir, "app.log")}
+	w.SetMaxBackups(4).SetMaxAge(48 * time.Hour).SetCompress(true)
+	w.intClean(now)
+
+	infos, _ := ioutil.ReadDir(dir)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	want := []string{
+		"app.log",
+		"app.log.20200101.gz",
+		"app.log.2020010103.001.gz",
+		"app.log.2020010103.gz",
+		"app.log.2020010104.gz",
+		"app.log.bak",
+		"app_err.log.2020010100",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("files = %v, want %v", names, want)
+		}
+	}
+
+	// the compressed file keeps its content and age
+	path := filepath.Join(dir, "app.log.2020010104.gz")
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(gz)
+	if string(b) != "app.log.2020010104" {
+		t.Errorf("content = %q", b)
+	}
+	info, _ := os.Stat(path)
+	if d := now.Sub(info.ModTime()); d < 59*time.Minute || d > 61*time.Minute {
+		t.Errorf("age = %v, want 1h", d)
+	}
+}
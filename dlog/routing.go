@@ -0,0 +1,140 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Route limits the records of a filter to those of its tags, packages and
+// levels, e.g. to write the SESSION logs to an access log.
+type Route struct {
+	// Tags of the records, patterns of path.Match such as "*_SLOW", empty
+	// matches every record
+	Tags []string
+	// Packages of the call sites with their subpackages, empty matches every
+	// record
+	Packages []string
+	// MaxLevel of the records, the Level of the filter is the least, 0 has
+	// no max
+	MaxLevel Level
+	// Exclusive keeps the records from the filters without a route, or else
+	// they continue to them too
+	Exclusive bool
+	// Continue is the least level of the records that continue to the
+	// filters without a route even if Exclusive, e.g. WARNING to keep them
+	// in the error log, 0 keeps all of them
+	Continue Level
+}
+
+// AddRoute adds a LogWriter of the records of route at lvl or higher, see
+// AddFilter.
+func (log Logger) AddRoute(name string, lvl Level, writer LogWriter, route Route) Logger {
+	log[name] = &Filter{Level: lvl, LogWriter: writer, Route: &route}
+	return log
+}
+
+func (r *Route) match(rec *LogRecord) bool {
+	if r.MaxLevel > 0 && rec.Level > r.MaxLevel {
+		return false
+	}
+	if len(r.Tags) > 0 {
+		ok := false
+		for _, pattern := range r.Tags {
+			if ok, _ = path.Match(pattern, rec.Tag); ok {
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(r.Packages) > 0 {
+		pkg := sourcePackage(rec.Source)
+		ok := false
+		for _, p := range r.Packages {
+			if ok = pkg == p || strings.HasPrefix(pkg, p+"/"); ok {
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// keeps reports whether r keeps rec from the filters without a route when it
+// matches.
+func (r *Route) keeps(rec *LogRecord) bool {
+	return r.Exclusive && (r.Continue == 0 || rec.Level < r.Continue)
+}
+
+// exclusive reports whether a filter of an exclusive route takes rec.
+func (log Logger) exclusive(rec *LogRecord, ov *overrideLevel) bool {
+	for _, filt := range log {
+		if filt.Route != nil && filt.Route.keeps(rec) && !ov.skip(filt, rec.Level) && filt.Route.match(rec) {
+			return true
+		}
+	}
+	return false
+}
+
+// routes reports whether filt takes rec by its route, excl is whether an
+// exclusive route takes rec.
+func (filt *Filter) routes(rec *LogRecord, excl bool) bool {
+	if filt.Route == nil {
+		return !excl
+	}
+	return filt.Route.match(rec)
+}
+
+type xmlRoute struct {
+	Exclusive bool     `xml:"exclusive,attr"`
+	Continue  string   `xml:"continue,attr"`
+	Tag       []string `xml:"tag"`
+	Package   []string `xml:"package"`
+	MaxLevel  string   `xml:"maxlevel"`
+}
+
+// xmlToRoute parses the route of a filter, nil if there is none.
+func xmlToRoute(filename string, xr *xmlRoute) (*Route, bool) {
+	if xr == nil {
+		return nil, true
+	}
+	route := &Route{Exclusive: xr.Exclusive}
+	for _, tag := range xr.Tag {
+		tag = strings.TrimSpace(tag)
+		if _, err := path.Match(tag, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Invalid route tag \"%s\" in %s: %s\n", tag, filename, err)
+			return nil, false
+		}
+		route.Tags = append(route.Tags, tag)
+	}
+	for _, pkg := range xr.Package {
+		route.Packages = append(route.Packages, strings.TrimSuffix(strings.TrimSpace(pkg), "/"))
+	}
+	if xr.MaxLevel != "" {
+		lvl, ok := ParseLevel(strings.TrimSpace(xr.MaxLevel))
+		if !ok {
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Unknown route maxlevel \"%s\" in %s\n", xr.MaxLevel, filename)
+			return nil, false
+		}
+		route.MaxLevel = lvl
+	}
+	if xr.Continue != "" {
+		lvl, ok := ParseLevel(strings.TrimSpace(xr.Continue))
+		if !ok {
+			fmt.Fprintf(os.Stderr, "LoadConfiguration: Error: Unknown route continue \"%s\" in %s\n", xr.Continue, filename)
+			return nil, false
+		}
+		route.Continue = lvl
+	}
+	return route, true
+}
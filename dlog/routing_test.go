@@ -0,0 +1,73 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestRoute(t *testing.T) {
+	service, errs, access, slow, sql := &recordWriter{}, &recordWriter{}, &recordWriter{}, &recordWriter{}, &recordWriter{}
+	l := make(Logger)
+	l.AddFilter("service", INFO, service)
+	l.AddFilter("service_err", WARNING, errs)
+	l.AddRoute("access", INFO, access, Route{Tags: []string{"SESSION"}, Exclusive: true, Continue: ERROR})
+	l.AddRoute("slow", INFO, slow, Route{Tags: []string{"*_SLOW"}, Exclusive: true})
+	l.AddRoute("sql", DEBUG, sql, Route{Packages: []string{"github.com/Xxianglei/gd/databases"}, MaxLevel: INFO})
+
+	l.LogWithTag("SESSION", INFO, "src", "session")
+	l.LogWithTag("SESSION_SLOW", WARNING, "src", "session slow")
+	l.LogWithTag("SERVER_SLOW", WARNING, "src", "server slow")
+	l.LogWithTag("SESSION", DEBUG, "src", "session debug")
+	l.LogWithTag("SESSION", ERROR, "src", "session fail")
+	l.Log(DEBUG, "github.com/Xxianglei/gd/databases/mysqldb.(*Db).Query:10", "select")
+	l.Log(ERROR, "github.com/Xxianglei/gd/databases/mysqldb.(*Db).Query:10", "select fail")
+	l.Log(INFO, "main.main:3", "app")
+
+	msgs := func(w *recordWriter) string {
+		var ms []string
+		for _, rec := range w.recs {
+			ms = append(ms, rec.Message)
+		}
+		return strings.Join(ms, "|")
+	}
+	for _, tt := range []struct {
+		name string
+		w    *recordWriter
+		want string
+	}{
+		{"service", service, "session fail|select fail|app"},
+		{"service_err", errs, "session fail|select fail"},
+		{"access", access, "session|session fail"},
+		{"slow", slow, "session slow|server slow"},
+		{"sql", sql, "select"},
+	} {
+		if got := msgs(tt.w); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestXmlToRoute(t *testing.T) {
+	var xf xmlFilter
+	err := xml.Unmarshal([]byte(`<filter enabled="true"><tag>slow</tag><route exclusive="true" continue="ERROR">`+
+		`<tag>*_SLOW</tag><package>github.com/Xxianglei/gd/net/</package><maxlevel>WARNING</maxlevel></route></filter>`), &xf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	route, ok := xmlToRoute("test.xml", xf.Route)
+	if !ok || !route.Exclusive || route.Tags[0] != "*_SLOW" || route.Packages[0] != "github.com/Xxianglei/gd/net" || route.MaxLevel != WARNING || route.Continue != ERROR {
+		t.Errorf("route = %+v", route)
+	}
+	if route, ok := xmlToRoute("test.xml", nil); !ok || route != nil {
+		t.Errorf("route = %+v", route)
+	}
+	if _, ok := xmlToRoute("test.xml", &xmlRoute{MaxLevel: "LOUD"}); ok {
+		t.Error("maxlevel LOUD is valid")
+	}
+}
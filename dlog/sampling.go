@@ -0,0 +1,293 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SampleRule limits the records of a tag, or of every call site.
+type SampleRule struct {
+	// Tag of the records, "" is the rule of every call site without a rule
+	// of its tag
+	Tag string
+	// First records pass in a Period, then 1 in Thereafter, 0 drops the rest
+	First      int
+	Thereafter int
+	// Period of the counts, default 1s
+	Period time.Duration
+	// Dedup collapses a record of the message of the last one into a
+	// "last message repeated X times" line
+	Dedup bool
+}
+
+type sampler struct {
+	lock   sync.RWMutex
+	rules  map[string]SampleRule // tag -> rule
+	def    *SampleRule
+	states map[string]*sampleState
+	stop   chan bool // stops run, nil without rules
+
+	enabled int32
+}
+
+// sampleState counts the records of a tag or call site.
+type sampleState struct {
+	lock       sync.Mutex
+	rule       SampleRule
+	label      string // tag=... or source=..., for the counters
+	logger     Logger
+	start      time.Time
+	count      int
+	suppressed int64
+	lastDrop   *LogRecord
+	last       *LogRecord
+	repeated   int64
+}
+
+var globalSampler = &sampler{states: make(map[string]*sampleState)}
+
+// SetSampleRules replaces the rules of sampling at any time, no rules log
+// every record. The counts of the previous rules are flushed first.
+func SetSampleRules(rules ...SampleRule) {
+	globalSampler.set(rules)
+}
+
+// SampleRules returns the rules of sampling.
+func SampleRules() []SampleRule {
+	s := globalSampler
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var rules []SampleRule
+	if s.def != nil {
+		rules = append(rules, *s.def)
+	}
+	for _, r := range s.rules {
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+var (
+	counterLock sync.RWMutex
+	counter     func(key string, n int64)
+)
+
+// SetCounter sets the counter of the records suppressed by sampling and
+// dropped by writers, pc sets pc.Incr. The keys are
+// dlog_suppressed,reason=rate|repeat,tag=... or source=..., and
+// dlog_dropped,writer=... of the writers of queue.go
+func SetCounter(c func(key string, n int64)) {
+	counterLock.Lock()
+	counter = c
+	counterLock.Unlock()
+}
+
+func count(key string, n int64) {
+	counterLock.RLock()
+	c := counter
+	counterLock.RUnlock()
+	if c != nil {
+		c(key, n)
+	}
+}
+
+func (s *sampler) set(rules []SampleRule) {
+	s.flush(time.Time{})
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.rules = make(map[string]SampleRule)
+	s.def = nil
+	s.states = make(map[string]*sampleState)
+	for _, r := range rules {
+		if r.Period <= 0 {
+			r.Period = time.Second
+		}
+		if r.Tag == "" {
+			def := r
+			s.def = &def
+		} else {
+			s.rules[r.Tag] = r
+		}
+	}
+
+	if len(rules) == 0 {
+		atomic.StoreInt32(&s.enabled, 0)
+		if s.stop != nil {
+			close(s.stop)
+			s.stop = nil
+		}
+		return
+	}
+	atomic.StoreInt32(&s.enabled, 1)
+	if s.stop == nil {
+		s.stop = make(chan bool)
+		go s.run(s.stop)
+	}
+}
+
+// run writes the summaries of the ended periods, without waiting for the
+// next record of their call sites.
+func (s *sampler) run(stop chan bool) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			s.flush(now)
+		}
+	}
+}
+
+// flush writes the summaries of the states whose period ended at now, the
+// zero time flushes every state.
+func (s *sampler) flush(now time.Time) {
+	s.lock.RLock()
+	states := make([]*sampleState, 0, len(s.states))
+	for _, st := range s.states {
+		states = append(states, st)
+	}
+	s.lock.RUnlock()
+
+	for _, st := range states {
+		st.lock.Lock()
+		var recs []*LogRecord
+		if now.IsZero() || now.Sub(st.start) >= st.rule.Period {
+			recs = s.endPeriod(st, now)
+		}
+		st.lock.Unlock()
+		st.logger.writeAll(recs)
+	}
+}
+
+// sample reports whether rec is written by log, the summaries of suppressed
+// records are written before it.
+func (log Logger) sample(rec *LogRecord) bool {
+	s := globalSampler
+	if atomic.LoadInt32(&s.enabled) == 0 {
+		return true
+	}
+	st := s.state(log, rec)
+	if st == nil {
+		return true
+	}
+
+	st.lock.Lock()
+	var recs []*LogRecord
+	if rec.Created.Sub(st.start) >= st.rule.Period {
+		recs = s.endPeriod(st, rec.Created)
+	}
+
+	if st.rule.Dedup {
+		if st.last != nil && st.last.Level == rec.Level && st.last.Message == rec.Message {
+			st.repeated++
+			st.lock.Unlock()
+			log.writeAll(recs)
+			return false
+		}
+		if r := s.repeatedSummary(st); r != nil {
+			recs = append(recs, r)
+		}
+		st.last = rec
+	}
+
+	st.count++
+	pass := st.count <= st.rule.First ||
+		(st.rule.Thereafter > 0 && (st.count-st.rule.First)%st.rule.Thereafter == 0)
+	if !pass {
+		st.suppressed++
+		st.lastDrop = rec
+	}
+	st.lock.Unlock()
+
+	log.writeAll(recs)
+	return pass
+}
+
+func (s *sampler) state(log Logger, rec *LogRecord) *sampleState {
+	s.lock.RLock()
+	rule, ok := s.rules[rec.Tag]
+	key, label := "tag:"+rec.Tag, "tag="+rec.Tag
+	if !ok {
+		if s.def == nil {
+			s.lock.RUnlock()
+			return nil
+		}
+		rule = *s.def
+		key, label = "source:"+rec.Source, "source="+rec.Source
+	}
+	st, ok := s.states[key]
+	s.lock.RUnlock()
+	if ok {
+		return st
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if st, ok := s.states[key]; ok {
+		return st
+	}
+	st = &sampleState{rule: rule, label: label, logger: log, start: rec.Created}
+	s.states[key] = st
+	return st
+}
+
+// endPeriod returns the summaries of st and starts a period at now, st is
+// locked.
+func (s *sampler) endPeriod(st *sampleState, now time.Time) []*LogRecord {
+	var recs []*LogRecord
+	if r := s.repeatedSummary(st); r != nil {
+		recs = append(recs, r)
+	}
+	if st.suppressed > 0 {
+		count("dlog_suppressed,reason=rate,"+st.label, st.suppressed)
+		r := *st.lastDrop
+		r.Created = time.Now()
+		r.Fields = nil
+		r.Message = fmt.Sprintf("suppressed %d logs in %s, the last: %s", st.suppressed, st.rule.Period, st.lastDrop.Message)
+		recs = append(recs, &r)
+	}
+	st.start = now
+	st.count = 0
+	st.suppressed = 0
+	st.lastDrop = nil
+	st.last = nil
+	return recs
+}
+
+// repeatedSummary returns the "repeated" line of st, or nil, st is locked.
+func (s *sampler) repeatedSummary(st *sampleState) *LogRecord {
+	if st.repeated == 0 {
+		return nil
+	}
+	count("dlog_suppressed,reason=repeat,"+st.label, st.repeated)
+	r := *st.last
+	r.Created = time.Now()
+	r.Fields = nil
+	r.Message = fmt.Sprintf("last message repeated %d times", st.repeated)
+	st.repeated = 0
+	return &r
+}
+
+// writeAll writes recs to the filters of their levels and routes, without
+// sampling.
+func (log Logger) writeAll(recs []*LogRecord) {
+	for _, rec := range recs {
+		ov := log.override(rec)
+		excl := log.exclusive(rec, ov)
+		for _, filt := range log {
+			if !ov.skip(filt, rec.Level) && filt.routes(rec, excl) {
+				filt.LogWrite(rec)
+			}
+		}
+	}
+}
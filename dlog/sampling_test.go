@@ -0,0 +1,77 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dlog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	var lock sync.Mutex
+	counts := make(map[string]int64)
+	SetCounter(func(key string, n int64) {
+		lock.Lock()
+		counts[key] += n
+		lock.Unlock()
+	})
+	defer SetCounter(nil)
+	defer SetSampleRules()
+
+	w := &recordWriter{}
+	l := make(Logger)
+	l.AddFilter("rec", DEBUG, w)
+
+	SetSampleRules(
+		SampleRule{First: 2, Thereafter: 3},
+		SampleRule{Tag: "DUP", First: 100, Dedup: true},
+	)
+	if len(SampleRules()) != 2 {
+		t.Errorf("rules = %+v", SampleRules())
+	}
+
+	// one call site, the 1st, 2nd, 5th and 8th pass
+	for i := 1; i <= 10; i++ {
+		l.Info("flood %d", i)
+	}
+	for i := 0; i < 5; i++ {
+		l.LogWithTag("DUP", ERROR, "src", "same")
+	}
+	l.LogWithTag("DUP", ERROR, "src", "other")
+
+	// the summaries of the rules are written when they are replaced
+	SetSampleRules()
+	l.Info("not sampled")
+
+	var msgs []string
+	for _, rec := range w.recs {
+		msgs = append(msgs, rec.Message)
+	}
+	want := []string{
+		"flood 1", "flood 2", "flood 5", "flood 8",
+		"same", "last message repeated 4 times", "other",
+		"suppressed 6 logs in 1s, the last: flood 10",
+		"not sampled",
+	}
+	if strings.Join(msgs, "|") != strings.Join(want, "|") {
+		t.Errorf("messages = %q, want %q", msgs, want)
+	}
+	if rec := w.recs[5]; rec.Tag != "DUP" || rec.Level != ERROR {
+		t.Errorf("summary = %+v", rec)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if counts["dlog_suppressed,reason=repeat,tag=DUP"] != 4 || len(counts) != 2 {
+		t.Errorf("counts = %v", counts)
+	}
+	for key, n := range counts {
+		if strings.HasPrefix(key, "dlog_suppressed,reason=rate,source=") && n != 6 {
+			t.Errorf("%s = %d, want 6", key, n)
+		}
+	}
+}
@@ -10,28 +10,49 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"time"
 )
 
-// This log writer sends output to a socket
-type SocketLogWriter chan *LogRecord
+// This log writer sends output to a socket, it does not reconnect, see
+// NetLogWriter. It was a chan *LogRecord before the policies of queue.go,
+// NewSocketLogWriter returns a *SocketLogWriter now, which is nil if the
+// socket cannot be dialed.
+type SocketLogWriter struct {
+	queue *logQueue
+}
 
-// This is the SocketLogWriter's output method
-func (w SocketLogWriter) LogWrite(rec *LogRecord) {
-	w <- rec
+// This is the SocketLogWriter's output method, a full buffer is handled by
+// the policy of SetPolicy.
+func (w *SocketLogWriter) LogWrite(rec *LogRecord) {
+	w.queue.push(rec, nil)
 }
 
-func (w SocketLogWriter) Close() {
-	close(w)
+func (w *SocketLogWriter) Close() {
+	close(w.queue.c)
+	w.queue.close()
 }
 
-func NewSocketLogWriter(proto, hostport string) SocketLogWriter {
+// Set the policy of a full buffer (chainable), default PolicyBlock. timeout
+// is the wait of PolicyBlockTimeout. It may be called at any time.
+func (w *SocketLogWriter) SetPolicy(policy Policy, timeout time.Duration) *SocketLogWriter {
+	w.queue.setPolicy(policy, timeout)
+	return w
+}
+
+func NewSocketLogWriter(proto, hostport string) *SocketLogWriter {
+	return NewSocketLogWriterFormat(proto, hostport, "")
+}
+
+// NewSocketLogWriterFormat sends the records formatted by format, e.g.
+// FORMAT_JSON for json lines. An empty format sends the LogRecord as json.
+func NewSocketLogWriterFormat(proto, hostport, format string) *SocketLogWriter {
 	sock, err := net.Dial(proto, hostport)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "NewSocketLogWriter(%q): %s\n", hostport, err)
 		return nil
 	}
 
-	w := SocketLogWriter(make(chan *LogRecord, LogBufferLength))
+	w := &SocketLogWriter{queue: newLogQueue("socket:"+hostport, PolicyBlock, 2*time.Millisecond)}
 
 	go func() {
 		defer func() {
@@ -40,19 +61,36 @@ func NewSocketLogWriter(proto, hostport string) SocketLogWriter {
 			}
 		}()
 
-		for rec := range w {
-			// Marshall into JSON
-			js, err := json.Marshal(rec)
-			if err != nil {
-				fmt.Fprint(os.Stderr, "SocketLogWriter(%q): %s", hostport, err)
-				return
+		formatCache := formatCacheType{}
+		broken := false
+		for rec := range w.queue.c {
+			// the records are dropped after an error instead of blocking the
+			// callers, NetLogWriter reconnects
+			if broken {
+				w.queue.drop(1)
+				w.queue.done()
+				continue
+			}
+
+			var js []byte
+			if format != "" {
+				js = []byte(FormatLogRecord(&formatCache, format, rec))
+			} else {
+				// Marshall into JSON
+				js, err = json.Marshal(rec)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "SocketLogWriter(%q): %s\n", hostport, err)
+					w.queue.done()
+					continue
+				}
 			}
 
 			_, err = sock.Write(js)
 			if err != nil {
-				fmt.Fprint(os.Stderr, "SocketLogWriter(%q): %s", hostport, err)
-				return
+				fmt.Fprintf(os.Stderr, "SocketLogWriter(%q): %s, records are dropped\n", hostport, err)
+				broken = true
 			}
+			w.queue.done()
 		}
 	}()
 
@@ -19,7 +19,7 @@ var stdout io.Writer = os.Stdout
 type ConsoleLogWriter struct {
 	closeOnce   sync.Once
 	format      string
-	w           chan *LogRecord
+	queue       *logQueue
 	formatCache formatCacheType
 }
 
@@ -27,41 +27,42 @@ type ConsoleLogWriter struct {
 func NewConsoleLogWriter() *ConsoleLogWriter {
 	consoleWriter := &ConsoleLogWriter{
 		format: "%L	%D %T	%M	%S",
-		w:           make(chan *LogRecord, LogBufferLength),
+		queue:       newLogQueue("console", PolicyBlockTimeout, 2*time.Millisecond),
 		formatCache: formatCacheType{},
 	}
+	consoleWriter.queue.alert = "fatal term log channel blocked!"
 	go consoleWriter.run(stdout)
 	return consoleWriter
 }
 func (c *ConsoleLogWriter) SetFormat(format string) {
 	c.format = format
 }
-func (c ConsoleLogWriter) run(out io.Writer) {
-	for rec := range c.w {
+
+// SetPolicy sets the policy of a full buffer, default PolicyBlockTimeout of
+// 2ms. timeout is the wait of PolicyBlockTimeout, 0 keeps it. It may be
+// called at any time.
+func (c *ConsoleLogWriter) SetPolicy(policy Policy, timeout time.Duration) {
+	c.queue.setPolicy(policy, timeout)
+}
+func (c *ConsoleLogWriter) run(out io.Writer) {
+	for rec := range c.queue.c {
 		fmt.Fprint(out, FormatLogRecord(&c.formatCache, c.format, rec))
+		c.queue.done()
 	}
 }
 
-// This is the ConsoleLogWriter's output method.  This will block if the output
-// buffer is full.
-func (c ConsoleLogWriter) LogWrite(rec *LogRecord) {
-	select {
-	case c.w <- rec:
-	default:
-		select {
-		case c.w <- rec:
-		case <-time.After(2 * time.Millisecond):
-			//add "fatal " prefix in stderr log to trigger sms alert
-			fmt.Fprintf(os.Stderr, "fatal term log channel blocked!%v\n", rec)
-		}
-	}
+// This is the ConsoleLogWriter's output method, a full output buffer is
+// handled by the policy of SetPolicy.
+func (c *ConsoleLogWriter) LogWrite(rec *LogRecord) {
+	c.queue.push(rec, nil)
 }
 
 // Close stops the logger from sending messages to standard output.  Attempts to
 // send log messages to this logger after a Close have undefined behavior.
-func (c ConsoleLogWriter) Close() {
+func (c *ConsoleLogWriter) Close() {
 	c.closeOnce.Do(func() {
-		close(c.w)
+		close(c.queue.c)
+		c.queue.close()
 		time.Sleep(50 * time.Millisecond) // Try to give console I/O time to complete
 	})
 }
@@ -98,7 +98,7 @@ func Stdoutf(format string, args ...interface{}) {
 func GetLevel() string {
 	var ret string
 	for tag, filter := range Global {
-		ret = ret + tag + ":" + filter.Level.String() + ","
+		ret = ret + tag + ":" + filter.level().String() + ","
 	}
 	return ret
 }
@@ -106,10 +106,21 @@ func GetLevel() string {
 func SetLevel(lvl int) {
 	level := Level(lvl)
 	for _, filter := range Global {
-		filter.Level = level
+		filter.setLevel(level)
 	}
 }
 
+// SetFilterLevel changes the level of the filter with the given tag only, it
+// may be called while logging.
+func SetFilterLevel(tag string, lvl Level) bool {
+	filter, ok := Global[tag]
+	if !ok {
+		return false
+	}
+	filter.setLevel(lvl)
+	return true
+}
+
 // Send a log message manually
 // Wrapper for (*Logger).Log
 func Log(lvl Level, source, message string) {
@@ -176,17 +187,17 @@ func Debug(arg0 interface{}, args ...interface{}) {
 		lvl = DEBUG
 	)
 	tag := ""
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
-		Global.intLogcTag(tag, clientIp, logId, lvl, first)
+		Global.intLogcTag(tag, clientIp, logId, url, lvl, first)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -194,17 +205,17 @@ func DebugT(tag string, arg0 interface{}, args ...interface{}) {
 	const (
 		lvl = DEBUG
 	)
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
-		Global.intLogcTag(tag, clientIp, logId, lvl, first)
+		Global.intLogcTag(tag, clientIp, logId, url, lvl, first)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -215,17 +226,17 @@ func Trace(arg0 interface{}, args ...interface{}) {
 		lvl = TRACE
 	)
 	tag := ""
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
-		Global.intLogcTag(tag, clientIp, logId, lvl, first)
+		Global.intLogcTag(tag, clientIp, logId, url, lvl, first)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -233,17 +244,17 @@ func TraceT(tag string, arg0 interface{}, args ...interface{}) {
 	const (
 		lvl = TRACE
 	)
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
-		Global.intLogcTag(tag, clientIp, logId, lvl, first)
+		Global.intLogcTag(tag, clientIp, logId, url, lvl, first)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -254,17 +265,17 @@ func Info(arg0 interface{}, args ...interface{}) {
 		lvl = INFO
 	)
 	tag := ""
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
-		Global.intLogcTag(tag, clientIp, logId, lvl, first)
+		Global.intLogcTag(tag, clientIp, logId, url, lvl, first)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -272,17 +283,17 @@ func InfoT(tag string, arg0 interface{}, args ...interface{}) {
 	const (
 		lvl = INFO
 	)
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
-		Global.intLogcTag(tag, clientIp, logId, lvl, first)
+		Global.intLogcTag(tag, clientIp, logId, url, lvl, first)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(arg0)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -294,18 +305,18 @@ func Warn(arg0 interface{}, args ...interface{}) {
 		lvl = WARNING
 	)
 	tag := ""
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
 		str := first()
-		Global.intLogfTag(tag, clientIp, logId, lvl, "%s", str)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, "%s", str)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -313,18 +324,18 @@ func WarnT(tag string, arg0 interface{}, args ...interface{}) {
 	const (
 		lvl = WARNING
 	)
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
 		str := first()
-		Global.intLogfTag(tag, clientIp, logId, lvl, "%s", str)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, "%s", str)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -336,18 +347,18 @@ func Error(arg0 interface{}, args ...interface{}) {
 		lvl = ERROR
 	)
 	tag := ""
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
 		str := first()
-		Global.intLogfTag(tag, clientIp, logId, lvl, "%s", str)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, "%s", str)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -355,18 +366,18 @@ func ErrorT(tag string, arg0 interface{}, args ...interface{}) {
 	const (
 		lvl = ERROR
 	)
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
 		str := first()
-		Global.intLogfTag(tag, clientIp, logId, lvl, "%s", str)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, "%s", str)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -378,18 +389,18 @@ func Critical(arg0 interface{}, args ...interface{}) {
 		lvl = CRITICAL
 	)
 	tag := ""
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
 		str := first()
-		Global.intLogfTag(tag, clientIp, logId, lvl, "%s", str)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, "%s", str)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
 	}
 }
 
@@ -397,18 +408,18 @@ func CriticalT(tag string, arg0 interface{}, args ...interface{}) {
 	const (
 		lvl = CRITICAL
 	)
-	clientIp, logId := batchGetGl()
+	clientIp, logId, url := batchGetGl()
 	switch first := arg0.(type) {
 	case string:
 		// Use the string as a format string
-		Global.intLogfTag(tag, clientIp, logId, lvl, first, args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, first, args...)
 	case func() string:
 		// Log the closure (no other arguments used)
 		str := first()
-		Global.intLogfTag(tag, clientIp, logId, lvl, "%s", str)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, "%s", str)
 	default:
 		// Build a format string so that it will be similar to Sprint
-		Global.intLogfTag(tag, clientIp, logId, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
+		Global.intLogfTag(tag, clientIp, logId, url, lvl, fmt.Sprint(first)+strings.Repeat(" %v", len(args)), args...)
 	}
 
 }
@@ -416,9 +427,10 @@ func CriticalT(tag string, arg0 interface{}, args ...interface{}) {
 var batchGLKeys = []interface{}{
 	gl.ClientIp,
 	gl.LogId,
+	gl.Url,
 }
 
-func batchGetGl() (ip, logId string) {
+func batchGetGl() (ip, logId, url string) {
 	vs := gl.BatchGet(batchGLKeys)
 	if vs == nil {
 		return
@@ -431,5 +443,9 @@ func batchGetGl() (ip, logId string) {
 	if ok && logIdo != nil {
 		logId, _ = logIdo.(string)
 	}
+	urlo, ok := vs[gl.Url]
+	if ok && urlo != nil {
+		url, _ = urlo.(string)
+	}
 	return
 }
@@ -0,0 +1,232 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+// Package gdtest runs an Engine in process for tests. The servers listen on
+// ephemeral ports, the grpc server on an in-memory connection, and the Engine
+// is stopped when the test finishes.
+package gdtest
+
+import (
+	"context"
+	"fmt"
+	"github.com/Xxianglei/gd"
+	"github.com/Xxianglei/gd/config"
+	"github.com/Xxianglei/gd/net/dgrpc"
+	"github.com/Xxianglei/gd/net/dhttp"
+	"github.com/Xxianglei/gd/net/dogrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"gopkg.in/ini.v1"
+	"net"
+	"testing"
+	"time"
+)
+
+const (
+	StartTimeout = 10 * time.Second
+	StopTimeout  = 30 * time.Second
+
+	bufSize = 1 << 20
+)
+
+type Option func(*options)
+
+type options struct {
+	conf   string
+	http   bool
+	grpc   bool
+	rpc    bool
+	engine []gd.Option
+}
+
+// WithConfig sets the ini content of the in-memory config.
+func WithConfig(conf string) Option {
+	return func(o *options) {
+		o.conf = conf
+	}
+}
+
+// WithHttp runs the http server, register handlers with Engine.SetHttpServer.
+func WithHttp() Option {
+	return func(o *options) {
+		o.http = true
+	}
+}
+
+// WithGrpc runs the grpc server, register handlers with Engine.SetGrpcServer.
+func WithGrpc() Option {
+	return func(o *options) {
+		o.grpc = true
+	}
+}
+
+// WithRpc runs the dogrpc server, add handlers to Engine.RpcServer.
+func WithRpc() Option {
+	return func(o *options) {
+		o.rpc = true
+	}
+}
+
+// WithEngineOptions passes options to gd.New.
+func WithEngineOptions(opts ...gd.Option) Option {
+	return func(o *options) {
+		o.engine = append(o.engine, opts...)
+	}
+}
+
+// Server is a running Engine and the clients of its servers.
+type Server struct {
+	*gd.Engine
+
+	// HttpAddr and RpcAddr are the addresses the servers listen on
+	HttpAddr string
+	RpcAddr  string
+
+	HttpClient *dhttp.HttpClient
+	// RpcClient has a request timeout of 1s, its DogInvoke calls the dog
+	// server of the Engine and Invoke a server of dogrpc.NewRpcServer
+	RpcClient *dogrpc.RpcClient
+
+	t        testing.TB
+	grpcLis  *bufconn.Listener
+	runError chan error
+}
+
+// Start creates an Engine, calls setup to register handlers and runs it. It
+// returns when all components are started, the Engine is stopped by
+// t.Cleanup.
+func Start(t testing.TB, setup func(e *gd.Engine), opts ...Option) *Server {
+	t.Helper()
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	f, err := ini.Load([]byte(o.conf))
+	if err != nil {
+		t.Fatalf("gdtest: load config fail,%v", err)
+	}
+
+	s := &Server{
+		t:        t,
+		runError: make(chan error, 1),
+	}
+
+	// servers not asked for stay off whatever the config says
+	engineOpts := []gd.Option{
+		gd.WithConfig(config.NewConf(f)),
+		gd.WithSignal(false),
+		gd.WithHttpPort(0),
+		gd.WithGrpcPort(0),
+		gd.WithRpcPort(0),
+		gd.WithHealthPort(0),
+		gd.WithProbePort(0),
+	}
+
+	if o.http {
+		l := s.listen()
+		s.HttpAddr = l.Addr().String()
+		engineOpts = append(engineOpts, gd.WithListener(gd.ComponentHttp, l), gd.WithHttpPort(port(l)))
+	}
+	if o.rpc {
+		l := s.listen()
+		s.RpcAddr = l.Addr().String()
+		engineOpts = append(engineOpts, gd.WithListener(gd.ComponentRpc, l), gd.WithRpcPort(port(l)))
+	}
+	if o.grpc {
+		s.grpcLis = bufconn.Listen(bufSize)
+		engineOpts = append(engineOpts, gd.WithListener(gd.ComponentGrpc, s.grpcLis))
+	}
+
+	s.Engine = gd.New(append(engineOpts, o.engine...)...)
+	if setup != nil {
+		setup(s.Engine)
+	}
+
+	go func() {
+		s.runError <- s.Engine.Run()
+	}()
+	t.Cleanup(s.stop)
+
+	select {
+	case <-s.Engine.Ready():
+		if s.Engine.StartError() != nil {
+			t.Fatalf("gdtest: engine run fail,%v", <-s.runError)
+		}
+	case err := <-s.runError:
+		t.Fatalf("gdtest: engine run fail,%v", err)
+	case <-time.After(StartTimeout):
+		t.Fatalf("gdtest: engine not ready after %v", StartTimeout)
+	}
+
+	if o.http {
+		s.HttpClient = &dhttp.HttpClient{Domain: "http://" + s.HttpAddr}
+		if err := s.HttpClient.Start(); err != nil {
+			t.Fatalf("gdtest: http client start fail,%v", err)
+		}
+	}
+	if o.rpc {
+		s.RpcClient = dogrpc.NewClient(time.Second, 0)
+		s.RpcClient.AddAddr(s.RpcAddr)
+		t.Cleanup(s.RpcClient.Stop)
+	}
+	return s
+}
+
+// GrpcClient connects to the grpc server in memory, makeRawClient is the
+// generated constructor such as pb.NewGreeterClient wrapped to return
+// interface{}. The client is stopped by t.Cleanup.
+func (s *Server) GrpcClient(makeRawClient func(conn *grpc.ClientConn) (interface{}, error)) *dgrpc.GrpcClient {
+	s.t.Helper()
+	if s.grpcLis == nil {
+		s.t.Fatal("gdtest: grpc server not running, use WithGrpc")
+	}
+
+	c := &dgrpc.GrpcClient{
+		Target: "bufnet",
+		Dialer: func(ctx context.Context, addr string) (net.Conn, error) {
+			return s.grpcLis.Dial()
+		},
+	}
+	if err := c.Start(makeRawClient); err != nil {
+		s.t.Fatalf("gdtest: grpc client start fail,%v", err)
+	}
+	s.t.Cleanup(c.Stop)
+	return c
+}
+
+func (s *Server) listen() net.Listener {
+	s.t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		s.t.Fatalf("gdtest: listen fail,%v", err)
+	}
+	return l
+}
+
+func (s *Server) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), StopTimeout)
+	defer cancel()
+
+	if err := s.Engine.Stop(ctx); err != nil {
+		s.t.Errorf("gdtest: engine stop fail,%v", err)
+		return
+	}
+	select {
+	case err := <-s.runError:
+		if err != nil {
+			s.t.Errorf("gdtest: engine run fail,%v", err)
+		}
+	default:
+	}
+}
+
+func port(l net.Listener) int {
+	if addr, ok := l.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	panic(fmt.Sprintf("gdtest: listener %s is not tcp", l.Addr()))
+}
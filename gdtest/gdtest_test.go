@@ -0,0 +1,99 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gdtest_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Xxianglei/gd"
+	de "github.com/Xxianglei/gd/derror"
+	"github.com/Xxianglei/gd/gdtest"
+	pb "github.com/Xxianglei/gd/net/dgrpc/sample/helloworld"
+	"github.com/Xxianglei/gd/net/dogrpc"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"net/http"
+	"testing"
+)
+
+type greeter struct{}
+
+func (g *greeter) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: "Hello " + in.Name}, nil
+}
+
+func (g *greeter) RegisterHandler(s *grpc.Server) error {
+	pb.RegisterGreeterServer(s, g)
+	return nil
+}
+
+type pingReq struct {
+	Data string
+}
+
+type pingResp struct {
+	Ret string
+}
+
+func ping(req *pingReq) (code uint32, message string, err error, ret *pingResp) {
+	return uint32(de.RpcSuccess), "ok", nil, &pingResp{Ret: "pong"}
+}
+
+func TestStart(t *testing.T) {
+	s := gdtest.Start(t, func(e *gd.Engine) {
+		e.SetHttpServer(func(g *gin.Engine) error {
+			g.GET("/ping", func(c *gin.Context) {
+				c.String(http.StatusOK, "pong")
+			})
+			return nil
+		})
+		e.SetGrpcServer(&greeter{})
+		e.RpcServer.AddDogHandler(1024, ping)
+		if err := e.RpcServer.DogRpcRegister(); err != nil {
+			t.Fatal(err)
+		}
+	}, gdtest.WithHttp(), gdtest.WithGrpc(), gdtest.WithRpc(),
+		gdtest.WithConfig("[Server]\nserverName = gdtest\nhttpPort = 10240\n"))
+
+	_, body, err := s.HttpClient.Method("GET", "/ping", nil, nil)
+	if err != nil || body != "pong" {
+		t.Errorf("http body = %q, err = %v", body, err)
+	}
+
+	req, _ := json.Marshal(&pingReq{Data: "ping"})
+	_, rsp, cerr := s.RpcClient.DogInvoke(1024, req)
+	if cerr != nil {
+		t.Fatalf("rpc err = %v", cerr)
+	}
+	var ret struct {
+		Result pingResp
+	}
+	if err := json.Unmarshal(rsp, &ret); err != nil || ret.Result.Ret != "pong" {
+		t.Errorf("rpc rsp = %s, err = %v", rsp, err)
+	}
+
+	c := s.GrpcClient(func(conn *grpc.ClientConn) (interface{}, error) {
+		return pb.NewGreeterClient(conn), nil
+	})
+	reply, err := c.GetRawClient().(pb.GreeterClient).SayHello(context.Background(), &pb.HelloRequest{Name: "gd"})
+	if err != nil || reply.Message != "Hello gd" {
+		t.Errorf("grpc reply = %v, err = %v", reply, err)
+	}
+}
+
+func TestStartRpcInvoke(t *testing.T) {
+	s := gdtest.Start(t, func(e *gd.Engine) {
+		e.RpcServer = dogrpc.NewRpcServer()
+		e.RpcServer.AddHandler(1, func(req []byte) (uint32, []byte) {
+			return 0, append([]byte("echo "), req...)
+		})
+	}, gdtest.WithRpc())
+
+	code, rsp, err := s.RpcClient.Invoke(1, []byte("ping"))
+	if err != nil || code != 0 || string(rsp) != "echo ping" {
+		t.Errorf("rpc code = %d, rsp = %q, err = %v", code, rsp, err)
+	}
+}
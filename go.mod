@@ -3,6 +3,7 @@ module github.com/Xxianglei/gd
 go 1.14
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/Pallinder/go-randomdata v1.2.0
 	github.com/alecthomas/log4go v0.0.0-20180109082532-d146e6b86faa
@@ -33,5 +34,6 @@ require (
 	golang.org/x/net v0.0.0-20200520004742-59133d7f0dd7
 	google.golang.org/grpc v1.34.0
 	gopkg.in/ini.v1 v1.57.0
+	gopkg.in/yaml.v2 v2.3.0
 	moul.io/http2curl v1.0.0 // indirect
 )
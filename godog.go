@@ -6,17 +6,25 @@
 package gd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/Xxianglei/gd/config"
+	"github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/net/dgrpc"
 	"github.com/Xxianglei/gd/net/dhttp"
 	"github.com/Xxianglei/gd/net/dogrpc"
-	"github.com/Xxianglei/gd/runtime/helper"
+	"github.com/Xxianglei/gd/net/inherit"
 	"github.com/Xxianglei/gd/runtime/pc"
 	"github.com/Xxianglei/gd/runtime/stat"
+	"github.com/Xxianglei/gd/server/register"
 	"github.com/Xxianglei/gd/utls"
 	"google.golang.org/grpc"
+	"net"
+	"os"
 	"runtime"
-	"syscall"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,22 +32,76 @@ type Engine struct {
 	HttpServer *dhttp.HttpServer
 	RpcServer  *dogrpc.RpcServer
 	GrpcServer *dgrpc.GrpcServer
+
+	// NewRegister creates the register used for [Register], default zookeeper
+	NewRegister func() register.DogRegister
+	registers   []register.DogRegister
+	components  []*componentEntry
+	checkers    []checkerEntry
+	fatal       chan error
+	upgraded    bool
+	resource    resourceLimits
+
+	conf      *config.Conf
+	log       dlog.Logger
+	signal    bool
+	ports     map[string]int
+	listeners map[string]net.Listener
+
+	shutdown   chan os.Signal
+	hup        chan os.Signal
+	upgradeSig chan os.Signal
+	runningCh  chan bool // Running for the Engine of Default, nil for New
+
+	initOnce sync.Once
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+	ready    chan struct{}
+	running  int32
+	startErr error
 }
 
-func Default() *Engine {
+// New creates an Engine. Without options it reads the package config like
+// Default, but it does not init the log from [Log], see InitLog.
+func New(opts ...Option) *Engine {
 	e := &Engine{
 		HttpServer: &dhttp.HttpServer{
 			NoGinLog: true,
 		},
 		RpcServer:  dogrpc.NewDogRpcServer(),
 		GrpcServer: &dgrpc.GrpcServer{},
+		signal:     true,
+		ports:      make(map[string]int),
+		listeners:  make(map[string]net.Listener),
+		shutdown:   make(chan os.Signal, 1),
+		hup:        make(chan os.Signal, 1),
+		upgradeSig: make(chan os.Signal, 1),
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
+}
+
+// Default creates an Engine from the package config and inits the log. It
+// receives signals through the package channels Shutdown, Hup and Upgrade.
+func Default() *Engine {
+	e := New()
+	e.shutdown = Shutdown
+	e.hup = Hup
+	e.upgradeSig = Upgrade
+	e.runningCh = Running
 
 	InitLog()
 	return e
 }
 
+// InitLog writes the log config of [Log] and loads it if enabled, and
+// subscribes the keys of [Log] that take effect on reload.
 func InitLog() {
+	// the level of every filter if the log config is not written
+	var levelTags []string
 	enable := Config("Log", "enable").MustBool(false)
 	if enable {
 		var port int
@@ -51,165 +113,257 @@ func InitLog() {
 			port = Config("Server", "grpcPort").MustInt()
 		}
 
-		if err := restoreLogConfig("", Config("Server", "serverName").String(),
-			port, Config("Log", "level").String(), Config("Log", "logDir").String()); err != nil {
+		var conf config.LogConf
+		if err := BindConfig("Log", &conf); err != nil {
+			panic(fmt.Sprintf("restoreLogConfig occur error:%v", err))
+		}
+		var err error
+		levelTags, err = restoreLogConfig("", Config("Server", "serverName").String(), port, conf)
+		if err != nil {
 			panic(fmt.Sprintf("restoreLogConfig occur error:%v", err))
 		}
 
 		LoadConfiguration(logConfigFile)
 	}
+
+	SubscribeConfig("Log", "level", func(c config.Change) {
+		lvl, ok := dlog.ParseLevel(c.New)
+		if !ok {
+			Error("config Log level %q is invalid, ignore", c.New)
+			return
+		}
+		if !enable {
+			dlog.SetLevel(int(lvl))
+		}
+		for _, tag := range levelTags {
+			dlog.SetFilterLevel(tag, lvl)
+		}
+		Info("log level changed from %q to %q", c.Old, c.New)
+	})
+	for _, key := range []string{"sampleFirst", "sampleThereafter", "dedup"} {
+		SubscribeConfig("Log", key, func(config.Change) {
+			updateSampleRule()
+		})
+	}
 }
 
-// Engine Run
+// Run runs the Engine until a signal arrives or Stop is called.
 func (e *Engine) Run() error {
-	Info("- - - - - - - - - - - - - - - - - - -")
-	Info("process start")
+	return e.RunContext(context.Background())
+}
+
+// RunContext starts all components and blocks until ctx is done, Stop is
+// called, a signal arrives or a server fails. The components are stopped
+// before it returns. An Engine runs once, it fails when run again.
+func (e *Engine) RunContext(ctx context.Context) (err error) {
+	e.init()
+	if !atomic.CompareAndSwapInt32(&e.running, 0, 1) {
+		return errors.New("engine can run only once")
+	}
+	defer close(e.done)
+	started := false
+	defer func() {
+		if !started {
+			e.startErr = err
+			close(e.ready)
+		}
+	}()
+
+	e.info("- - - - - - - - - - - - - - - - - - -")
+	e.info("process start...")
 	// register signal
-	e.Signal()
+	if e.signal {
+		e.Signal()
+		defer e.stopSignal()
+	}
+
+	// check the config against the declared keys
+	if err := e.ValidateConfig(); err != nil {
+		if e.Config("Process", "strictConfig").MustBool(false) {
+			e.error("Invalid config, error = %s", err.Error())
+			return err
+		}
+		e.warn("Invalid config, error = %s", err.Error())
+	}
 
 	// dump when error occurs
-	logDir := Config("Log", "logDir").String()
-	file, err := utls.Dump(logDir, Config("Server", "serverName").String())
+	logDir := e.Config("Log", "logDir").String()
+	file, err := utls.Dump(logDir, e.Config("Server", "serverName").String())
 	if err != nil {
-		Error("Error occurs when initialize dump dumpPanic file, error = %s", err.Error())
+		e.error("Error occurs when initialize dump dumpPanic file, error = %s", err.Error())
 	}
 
 	// output exit info
 	defer func() {
-		Info("server stop...code: %d", runtime.NumGoroutine())
-		time.Sleep(time.Second)
-		Info("server stop...ok")
-		Info("- - - - - - - - - - - - - - - - - - -")
+		e.info("server stop...code: %d", runtime.NumGoroutine())
+		e.info("server stop...ok")
+		e.info("- - - - - - - - - - - - - - - - - - -")
 		if err := utls.ReviewDumpPanic(file); err != nil {
-			Error("Failed to review dump dumpPanic file, error = %s", err.Error())
+			e.error("Failed to review dump dumpPanic file, error = %s", err.Error())
 		}
+		e.flushLog()
 	}()
 
 	// init cpu and memory
 	err = e.initCPUAndMemory()
 	if err != nil {
-		Error("Cannot init CPU and memory module, error = %s", err.Error())
+		e.error("Cannot init CPU and memory module, error = %s", err.Error())
 		return err
 	}
 
 	// init falcon
-	falconEnable := Config("Statistics", "falcon").MustBool(false)
+	falconEnable := e.Config("Statistics", "falcon").MustBool(false)
 	if falconEnable {
 		pc.Init()
 		defer pc.ClosePerfCounter()
 	}
 
 	// init stat
-	statEnable := Config("Statistics", "stat").MustBool(false)
+	statEnable := e.Config("Statistics", "stat").MustBool(false)
 	if statEnable {
-		statInterval := Config("Statistics", "statInterval").MustInt64(5)
+		statInterval := e.Config("Statistics", "statInterval").MustInt64(5)
 		statFile := "stat.log"
 		if logDir != "" {
 			statFile = logDir + "/stat.log"
 		}
 		stat.StatMgrInstance().Init(statFile, time.Second*time.Duration(statInterval))
-	}
-
-	// http server
-	httpPort := Config("Server", "httpPort").MustInt()
-	if httpPort > 0 {
-		Info("http server try listen port:%d", httpPort)
 
-		e.HttpServer.HttpServerRunHost = fmt.Sprintf(":%d", httpPort)
-		if err = e.HttpServer.Run(); err != nil {
-			Error("Http server occur error in running application, error = %s", err.Error())
-			return err
-		}
-		defer e.HttpServer.Stop()
-
-		if falconEnable {
-			pc.SetRunPort(httpPort)
-		}
+		SubscribeConfig("Statistics", "statInterval", func(c config.Change) {
+			statInterval := e.Config("Statistics", "statInterval").MustInt64(5)
+			stat.StatMgrInstance().SetStatGap(time.Second * time.Duration(statInterval))
+		})
 	}
 
-	// grpc server
-	grpcPort := Config("Server", "grpcPort").MustInt()
-	if grpcPort > 0 {
-		Info("grpc server try listen port:%d", grpcPort)
-
-		e.GrpcServer.GrpcRunPort = grpcPort
-		e.GrpcServer.ServiceName = Config("Server", "serverName").String()
-		if err = e.GrpcServer.Run(); err != nil {
-			Error("Grpc server occur error in running application, error = %s", err.Error())
-			return err
+	httpPort := e.port(ComponentHttp, "Server", "httpPort")
+	grpcPort := e.port(ComponentGrpc, "Server", "grpcPort")
+	healthPort := e.port(ComponentHealth, "Process", "healthPort")
+	rpcPort := e.port(ComponentRpc, "Server", "rpcPort")
+	probePort := e.port(ComponentProbe, "Process", "probePort")
+	if falconEnable {
+		if httpPort > 0 {
+			pc.SetRunPort(httpPort)
 		}
-		defer e.GrpcServer.Stop()
-
-		if falconEnable {
+		if grpcPort > 0 {
 			pc.SetRunPort(grpcPort)
 		}
 	}
 
-	// health
-	healthPort := Config("Process", "healthPort").MustInt()
-	if healthPort > 0 {
-		Info("health server try listen port:%d", healthPort)
-
-		host := fmt.Sprintf(":%d", healthPort)
-		health := &helper.Helper{Host: host}
-		if err := health.Start(); err != nil {
-			Error("start health failed on %s\n", host)
-			return err
-		}
-		defer health.Close()
+	// start servers after the components of application, stop in reverse order
+	if err = e.addServerComponents(httpPort, grpcPort, healthPort, rpcPort, probePort); err != nil {
+		e.error("Cannot add server components, error = %s", err.Error())
+		return err
+	}
+	if err = e.startComponents(); err != nil {
+		e.error("Components occur error in running application, error = %s", err.Error())
+		return err
+	}
+	defer e.stopComponents()
+	e.info("process start ok")
+	started = true
+	close(e.ready)
+
+	// tell the parent process to stop if started by upgrade
+	if err = inherit.Ready(); err != nil {
+		e.error("Cannot notify parent process ready, error = %s", err.Error())
 	}
 
-	// rpc server
-	rpcPort := Config("Server", "rpcPort").MustInt()
-	if rpcPort > 0 {
-		Info("rpc server try listen port:%d", rpcPort)
-
-		if err = e.RpcServer.Run(rpcPort); err != nil {
-			Error("rpc server occur error in running application, error = %s", err.Error())
+	// stop all when asked to, any server fails or a new process takes over,
+	// upgrading is nil unless a new process is starting
+	var upgrading chan error
+	for {
+		select {
+		case <-ctx.Done():
+			e.info("context done, to stop server...")
+			return nil
+		case <-e.stop:
+			e.info("engine stopped, to stop server...")
+			return nil
+		case <-e.runningCh:
+			return nil
+		case sig := <-e.shutdown:
+			e.info("receive signal: %v, to stop server...", sig)
+			return nil
+		case sig := <-e.hup:
+			e.info("receive signal: %v, to reload config...", sig)
+			e.reloadConfig()
+		case err = <-e.fatal:
+			e.error("Server occur error in running application, to stop server, error = %s", err.Error())
 			return err
+		case sig := <-e.upgradeSig:
+			if upgrading != nil {
+				e.info("receive signal: %v, upgrade in progress, ignore", sig)
+				continue
+			}
+			e.info("receive signal: %v, to upgrade process...", sig)
+			upgrading = make(chan error, 1)
+			go e.upgrade(upgrading)
+		case err = <-upgrading:
+			upgrading = nil
+			if err != nil {
+				e.error("upgrade fail, keep serving, error = %s", err.Error())
+				continue
+			}
+			e.upgraded = true
+			e.info("upgrade ok, to stop server...")
+			return nil
 		}
-		defer e.RpcServer.Stop()
 	}
-
-	<-Running
-	return nil
 }
 
-func (e *Engine) initCPUAndMemory() error {
-	maxCPU := Config("Process", "maxCPU").MustInt()
-	numCpus := runtime.NumCPU()
-	if maxCPU <= 0 {
-		if numCpus > 3 {
-			maxCPU = numCpus / 2
-		} else {
-			maxCPU = 1
-		}
-	} else if maxCPU > numCpus {
-		maxCPU = numCpus
+// flushLog waits up to [Log] flushTimeout for the buffered logs to be
+// written, the logs left are reported to stderr as they are no longer
+// logged.
+func (e *Engine) flushLog() {
+	timeout := time.Second * time.Duration(e.Config("Log", "flushTimeout").MustInt64(3))
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := dlog.Flush(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "flush log fail, logs are lost, error = %s\n", err.Error())
 	}
-	runtime.GOMAXPROCS(maxCPU)
+}
 
-	if Config("Process", "maxMemory").String() != "" {
-		maxMemory, err := utls.ParseMemorySize(Config("Process", "maxMemory").String())
-		if err != nil {
-			Crash(fmt.Sprintf("conf field illgeal, max_memory:%s, error:%s", Config("Process", "maxMemory").String(), err.Error()))
-		}
+// Stop makes Run return and waits until the components are stopped or ctx
+// is done.
+func (e *Engine) Stop(ctx context.Context) error {
+	e.init()
+	e.stopOnce.Do(func() {
+		close(e.stop)
+	})
 
-		var rlimit syscall.Rlimit
-		syscall.Getrlimit(syscall.RLIMIT_AS, &rlimit)
-		Info("old rlimit mem:%v", rlimit)
-		rlimit.Cur = uint64(maxMemory)
-		rlimit.Max = uint64(maxMemory)
-		err = syscall.Setrlimit(syscall.RLIMIT_AS, &rlimit)
-		if err != nil {
-			Crash(fmt.Sprintf("syscall Setrlimit fail, rlimit:%v, error:%s", rlimit, err.Error()))
-		} else {
-			syscall.Getrlimit(syscall.RLIMIT_AS, &rlimit)
-			Info("new rlimit mem:%v", rlimit)
-		}
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
+
+// Ready is closed when Run has started all components, or has failed to
+// start, see StartError.
+func (e *Engine) Ready() <-chan struct{} {
+	e.init()
+	return e.ready
+}
+
+// StartError waits for Ready and returns why Run failed to start, nil if it
+// started.
+func (e *Engine) StartError() error {
+	<-e.Ready()
+	return e.startErr
+}
+
+// Done is closed when Run returns.
+func (e *Engine) Done() <-chan struct{} {
+	e.init()
+	return e.done
+}
 
-	return nil
+func (e *Engine) init() {
+	e.initOnce.Do(func() {
+		e.stop = make(chan struct{})
+		e.done = make(chan struct{})
+		e.ready = make(chan struct{})
+	})
 }
 
 func (e *Engine) SetHttpServer(init dhttp.HttpServerIniter) {
@@ -220,9 +374,10 @@ func (e *Engine) SetGrpcServer(init dgrpc.IRegisterHandler) {
 	e.GrpcServer.Register(init)
 }
 
-// timeout Millisecond
+// timeout Millisecond, [Client] rpcTimeoutMs overrides it
 func NewRpcClient(timeout time.Duration, retryNum uint32) *dogrpc.RpcClient {
 	client := dogrpc.NewClient(timeout, retryNum)
+	client.OnStop(watchClientTimeout("rpcTimeoutMs", client.SetTimeout))
 	return client
 }
 
@@ -235,9 +390,23 @@ func NewHttpClient(Timeout time.Duration, Domain string) *dhttp.HttpClient {
 		Error("http client start occur error:%s", err.Error())
 		return nil
 	}
+	client.OnStop(watchClientTimeout("httpTimeoutMs", client.SetTimeout))
 	return client
 }
 
+// watchClientTimeout sets the timeout of a client to [Client] key now and on
+// reload, 0 keeps the one it is made with. The returned cancel stops it, the
+// client calls it on Stop so that it can be collected.
+func watchClientTimeout(key string, set func(time.Duration)) (cancel func()) {
+	timeout := func() time.Duration {
+		return time.Duration(Config("Client", key).MustInt64(0)) * time.Millisecond
+	}
+	set(timeout())
+	return SubscribeConfig("Client", key, func(config.Change) {
+		set(timeout())
+	})
+}
+
 func NewGrpcClient(target string, makeRawClient func(conn *grpc.ClientConn) (interface{}, error), serviceName string) *dgrpc.GrpcClient {
 	client := &dgrpc.GrpcClient{
 		Target:      target,
@@ -0,0 +1,172 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"context"
+	"github.com/Xxianglei/gd/config"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/ini.v1"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestEngine(t *testing.T, reply string) (*Engine, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := New(
+		WithConfig(config.NewConf(ini.Empty())),
+		WithSignal(false),
+		WithListener(ComponentHttp, l),
+	)
+	e.SetHttpServer(func(g *gin.Engine) error {
+		g.GET("/ping", func(c *gin.Context) {
+			c.String(http.StatusOK, reply)
+		})
+		return nil
+	})
+	return e, l.Addr().String()
+}
+
+func TestEngineNew(t *testing.T) {
+	e1, addr1 := newTestEngine(t, "e1")
+	e2, addr2 := newTestEngine(t, "e2")
+
+	errs := make(chan error, 2)
+	go func() { errs <- e1.Run() }()
+	go func() { errs <- e2.Run() }()
+
+	client := &http.Client{Timeout: time.Second}
+	for addr, want := range map[string]string{addr1: "e1", addr2: "e2"} {
+		var body []byte
+		for i := 0; i < 50; i++ {
+			rsp, err := client.Get("http://" + addr + "/ping")
+			if err == nil {
+				body, _ = ioutil.ReadAll(rsp.Body)
+				rsp.Body.Close()
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if string(body) != want {
+			t.Errorf("%s reply = %q, want %q", addr, body, want)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, e := range []*Engine{e1, e2} {
+		if err := e.Stop(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestClientTimeoutReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "conf.ini")
+	if err := ioutil.WriteFile(path, []byte("[Client]\nhttpTimeoutMs = 100\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetConfPath(path)
+
+	var timeout time.Duration
+	cancel := watchClientTimeout("httpTimeoutMs", func(d time.Duration) {
+		timeout = d
+	})
+	if timeout != 100*time.Millisecond {
+		t.Errorf("timeout = %s, want 100ms", timeout)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("[Client]\nhttpTimeoutMs = 200\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if timeout != 200*time.Millisecond {
+		t.Errorf("timeout = %s, want 200ms on reload", timeout)
+	}
+
+	client := NewHttpClient(time.Second, "http://127.0.0.1")
+	client.OnStop(func() { timeout = 0 })
+	client.Stop()
+	if timeout != 0 {
+		t.Error("functions of OnStop not called by Stop")
+	}
+	cancel()
+	if err := ioutil.WriteFile(path, []byte("[Client]\nhttpTimeoutMs = 300\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if timeout != 0 {
+		t.Errorf("timeout = %s, want no change after cancel", timeout)
+	}
+}
+
+func TestEngineRunOnce(t *testing.T) {
+	f := ini.Empty()
+	f.Section("Process").Key("maxMemory").SetValue("2x")
+	e := New(WithConfig(config.NewConf(f)), WithSignal(false))
+
+	err := e.Run()
+	if err == nil {
+		t.Fatal("run with an invalid maxMemory should fail")
+	}
+	select {
+	case <-e.Ready():
+	default:
+		t.Fatal("ready not closed on a failed start")
+	}
+	if e.StartError() != err {
+		t.Errorf("start error = %v, want %v", e.StartError(), err)
+	}
+	if err := e.Run(); err == nil {
+		t.Error("second run should fail")
+	}
+}
+
+func TestEngineRunningOfDefault(t *testing.T) {
+	e := New(WithConfig(config.NewConf(ini.Empty())), WithSignal(false))
+	errs := make(chan error, 1)
+	go func() { errs <- e.Run() }()
+	<-e.Ready()
+	if err := e.StartError(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case Running <- true:
+		t.Error("engine of New stopped by Running")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Error(err)
+	}
+}
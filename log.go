@@ -8,10 +8,13 @@ package gd
 import (
 	"encoding/xml"
 	"fmt"
+	"github.com/Xxianglei/gd/config"
 	"github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/utls"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -24,8 +27,17 @@ var (
 )
 
 type xmlLoggerConfig struct {
-	ScribeCategory string      `xml:"scribeCategory"`
-	Filter         []xmlFilter `xml:"filter"`
+	ScribeCategory string          `xml:"scribeCategory"`
+	Filter         []xmlFilter     `xml:"filter"`
+	Sampling       []xmlSampleRule `xml:"sampling>rule"`
+}
+
+type xmlSampleRule struct {
+	Tag        string `xml:"tag,omitempty"`
+	First      int    `xml:"first"`
+	Thereafter int    `xml:"thereafter"`
+	Period     string `xml:"period,omitempty"`
+	Dedup      bool   `xml:"dedup"`
 }
 
 type xmlProperty struct {
@@ -39,6 +51,15 @@ type xmlFilter struct {
 	Level    string        `xml:"level"`
 	Type     string        `xml:"type"`
 	Property []xmlProperty `xml:"property"`
+	Route    *xmlRoute     `xml:"route,omitempty"`
+}
+
+type xmlRoute struct {
+	Exclusive bool     `xml:"exclusive,attr"`
+	Continue  string   `xml:"continue,attr,omitempty"`
+	Tag       []string `xml:"tag"`
+	Package   []string `xml:"package,omitempty"`
+	MaxLevel  string   `xml:"maxlevel,omitempty"`
 }
 
 func getInfoFileName(binName string, port int) string {
@@ -55,9 +76,21 @@ func getWarnFileName(binName string, port int) string {
 	return fmt.Sprintf("%s_err_%d.log", binName, port)
 }
 
-func restoreLogConfig(configFilePath string, binName string, port int, logLevel string, logDir string) error {
+// getRouteFileName returns the file of the routed logs of a name, e.g.
+// access.log.
+func getRouteFileName(name string, port int) string {
+	if port == 0 {
+		return fmt.Sprintf("%s.log", name)
+	}
+	return fmt.Sprintf("%s_%d.log", name, port)
+}
+
+// restoreLogConfig writes the log config of conf to configFilePath, it
+// returns the tags of the filters at the level of conf.
+func restoreLogConfig(configFilePath string, binName string, port int, conf config.LogConf) ([]string, error) {
 	l.Lock()
 	defer l.Unlock()
+	logLevel, logDir, format := conf.Level, conf.LogDir, conf.Format
 	if logDir == "" {
 		logDir = defaultLogDir
 	}
@@ -70,10 +103,18 @@ func restoreLogConfig(configFilePath string, binName string, port int, logLevel
 		logLevel = "DEBUG"
 	}
 
+	switch format {
+	case "", "text":
+		format = defaultFormat
+	case dlog.FORMAT_JSON:
+	default:
+		return nil, fmt.Errorf("invalid log format %v", format)
+	}
+
 	if binName == "" {
 		ex, err := os.Executable()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		exPath := filepath.Dir(ex)
 		if strings.Contains(exPath, "/") {
@@ -83,7 +124,7 @@ func restoreLogConfig(configFilePath string, binName string, port int, logLevel
 	}
 
 	if logLevel != "DEBUG" && logLevel != "INFO" && logLevel != "WARNING" && logLevel != "ERROR" {
-		return fmt.Errorf("invalid log level %v", logLevel)
+		return nil, fmt.Errorf("invalid log level %v", logLevel)
 	}
 
 	infoFileName := getInfoFileName(binName, port)
@@ -106,14 +147,17 @@ func restoreLogConfig(configFilePath string, binName string, port int, logLevel
 		Type:    "file",
 		Property: []xmlProperty{
 			xmlProperty{Name: "filename", Value: fmt.Sprintf("%s/%s", logDir, infoFileName)},
-			xmlProperty{Name: "format", Value: defaultFormat},
+			xmlProperty{Name: "format", Value: format},
 			xmlProperty{Name: "rotate", Value: "true"},
 			xmlProperty{Name: "maxsize", Value: "0M"},
 			xmlProperty{Name: "maxlines", Value: "0K"},
 			xmlProperty{Name: "hourly", Value: "true"},
 		},
 	}
+	info.Property = append(info.Property, retentionProperties(conf)...)
+	info.Property = append(info.Property, policyProperties(conf)...)
 	filters = append(filters, info)
+	levelTags := []string{info.Tag}
 	// warn
 	warn := xmlFilter{
 		Enabled: "true",
@@ -122,30 +166,127 @@ func restoreLogConfig(configFilePath string, binName string, port int, logLevel
 		Type:    "file",
 		Property: []xmlProperty{
 			xmlProperty{Name: "filename", Value: fmt.Sprintf("%s/%s", logDir, warnFileName)},
-			xmlProperty{Name: "format", Value: defaultFormat},
+			xmlProperty{Name: "format", Value: format},
 			xmlProperty{Name: "rotate", Value: "true"},
 			xmlProperty{Name: "maxsize", Value: "0M"},
 			xmlProperty{Name: "maxlines", Value: "0K"},
 			xmlProperty{Name: "hourly", Value: "true"},
 		},
 	}
+	warn.Property = append(warn.Property, retentionProperties(conf)...)
+	warn.Property = append(warn.Property, policyProperties(conf)...)
 	filters = append(filters, warn)
+	// access and slow logs, kept out of the others
+	if conf.Routing {
+		for _, route := range []xmlFilter{
+			routeFilter("access", logDir+"/"+getRouteFileName("access", port), logLevel, format, conf, "SESSION"),
+			routeFilter("slow", logDir+"/"+getRouteFileName("slow", port), logLevel, format, conf, "*_SLOW"),
+		} {
+			filters = append(filters, route)
+			levelTags = append(levelTags, route.Tag)
+		}
+	}
 
 	c := &xmlLoggerConfig{
 		Filter: filters,
 	}
+	if rule, ok := sampleRule(conf); ok {
+		c.Sampling = append(c.Sampling, xmlSampleRule{
+			First:      rule.First,
+			Thereafter: rule.Thereafter,
+			Dedup:      rule.Dedup,
+		})
+	}
 
 	bts, err := xml.Marshal(c)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = utls.Store2File(configFilePath, string(bts))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return levelTags, nil
+}
+
+// retentionProperties bound the rotated files of a file filter, see
+// dlog.FileLogWriter.SetMaxBackups.
+func retentionProperties(conf config.LogConf) []xmlProperty {
+	return []xmlProperty{
+		{Name: "maxbackups", Value: strconv.Itoa(conf.MaxBackups)},
+		{Name: "maxage", Value: conf.MaxAge.String()},
+		{Name: "compress", Value: strconv.FormatBool(conf.Compress)},
+	}
+}
+
+// routeFilter is a file filter of the logs of tags only, see dlog.Route. The
+// warnings and errors continue to the other filters too.
+func routeFilter(name, filename, level, format string, conf config.LogConf, tags ...string) xmlFilter {
+	filter := xmlFilter{
+		Enabled: "true",
+		Tag:     name,
+		Level:   level,
+		Type:    "file",
+		Property: []xmlProperty{
+			{Name: "filename", Value: filename},
+			{Name: "format", Value: format},
+			{Name: "rotate", Value: "true"},
+			{Name: "hourly", Value: "true"},
+		},
+		Route: &xmlRoute{Exclusive: true, Continue: "WARNING", Tag: tags},
+	}
+	filter.Property = append(filter.Property, retentionProperties(conf)...)
+	filter.Property = append(filter.Property, policyProperties(conf)...)
+	return filter
+}
+
+// policyProperties set what a file filter does when its buffer is full, see
+// dlog.FileLogWriter.SetPolicy.
+func policyProperties(conf config.LogConf) []xmlProperty {
+	if conf.Policy == "" {
+		return nil
+	}
+	return []xmlProperty{
+		{Name: "policy", Value: conf.Policy},
+		{Name: "timeout", Value: conf.BlockTimeout.String()},
+	}
+}
+
+// sampleRule returns the sampling of every call site by [Log], false if
+// logs are not sampled.
+func sampleRule(conf config.LogConf) (dlog.SampleRule, bool) {
+	if conf.SampleFirst <= 0 && !conf.Dedup {
+		return dlog.SampleRule{}, false
+	}
+	rule := dlog.SampleRule{First: conf.SampleFirst, Thereafter: conf.SampleThereafter, Dedup: conf.Dedup}
+	if rule.First <= 0 {
+		// dedup only
+		rule.First = math.MaxInt32
+	}
+	return rule, true
+}
+
+// updateSampleRule replaces the sampling of every call site by [Log], the
+// rules of tags set by log.xml or code are kept.
+func updateSampleRule() {
+	var conf config.LogConf
+	if err := BindConfig("Log", &conf); err != nil {
+		Error("config Log sampling is invalid, ignore, %v", err)
+		return
+	}
+	var rules []dlog.SampleRule
+	for _, r := range dlog.SampleRules() {
+		if r.Tag != "" {
+			rules = append(rules, r)
+		}
+	}
+	if rule, ok := sampleRule(conf); ok {
+		rules = append(rules, rule)
+	}
+	dlog.SetSampleRules(rules...)
+	Info("log sampling changed to %+v", rules)
 }
 
 // Wrapper for (*Logger).LoadConfiguration
@@ -153,6 +294,11 @@ func LoadConfiguration(filename string) {
 	dlog.LoadConfiguration(filename)
 }
 
+// With returns a logger with the fields of key value pairs, see dlog.With.
+func With(kv ...interface{}) *dlog.Entry {
+	return dlog.With(kv...)
+}
+
 // wrap log debug
 func Debug(arg0 interface{}, args ...interface{}) {
 	dlog.Debug(arg0, args...)
@@ -0,0 +1,16 @@
+//go:build go1.19
+// +build go1.19
+
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import "runtime/debug"
+
+// setMemoryLimit sets the soft memory limit of the runtime in bytes.
+var setMemoryLimit = func(limit int64) {
+	debug.SetMemoryLimit(limit)
+}
@@ -0,0 +1,12 @@
+//go:build !go1.19
+// +build !go1.19
+
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+// setMemoryLimit does nothing, the runtime has no memory limit before go1.19.
+var setMemoryLimit = func(limit int64) {}
@@ -18,6 +18,7 @@ import (
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"io/ioutil"
+	"net"
 	"sync"
 	"time"
 )
@@ -35,6 +36,7 @@ type GrpcClient struct {
 	GrpcCaPemFile      string
 	GrpcClientKeyFile  string
 	GrpcClientPemFile  string
+	Dialer             func(ctx context.Context, addr string) (net.Conn, error) // used instead of tcp to connect Target if set
 	startOnce          sync.Once
 	stopOnce           sync.Once
 	connect            *grpc.ClientConn
@@ -105,8 +107,19 @@ func (c *GrpcClient) DefaultClient() (*grpc.ClientConn, error) {
 	to, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var cc *grpc.ClientConn
-	var err error
+	dialOps := []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, roundrobin.Name)),
+		grpc.WithUnaryInterceptor(grpcMiddleware.ChainUnaryClient(
+			options.UnaryClientInterceptors...,
+		)),
+		grpc.WithStreamInterceptor(grpcMiddleware.ChainStreamClient(
+			options.StreamClientInterceptors...,
+		)),
+	}
+	if c.Dialer != nil {
+		dialOps = append(dialOps, grpc.WithContextDialer(c.Dialer))
+	}
+
 	if c.UseTls {
 		if c.GrpcCaPemFile == "" {
 			c.GrpcCaPemFile = "conf/ca.pem"
@@ -128,32 +141,12 @@ func (c *GrpcClient) DefaultClient() (*grpc.ClientConn, error) {
 		if err != nil {
 			return nil, err
 		}
-
-		cc, err = grpc.Dial(
-			c.Target,
-			grpc.WithTransportCredentials(cTls),
-			grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, roundrobin.Name)),
-			grpc.WithUnaryInterceptor(grpcMiddleware.ChainUnaryClient(
-				options.UnaryClientInterceptors...,
-			)),
-			grpc.WithStreamInterceptor(grpcMiddleware.ChainStreamClient(
-				options.StreamClientInterceptors...,
-			)),
-		)
+		dialOps = append(dialOps, grpc.WithTransportCredentials(cTls))
 	} else {
-		cc, err = grpc.Dial(
-			c.Target,
-			grpc.WithInsecure(),
-			grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, roundrobin.Name)),
-			grpc.WithUnaryInterceptor(grpcMiddleware.ChainUnaryClient(
-				options.UnaryClientInterceptors...,
-			)),
-			grpc.WithStreamInterceptor(grpcMiddleware.ChainStreamClient(
-				options.StreamClientInterceptors...,
-			)),
-		)
+		dialOps = append(dialOps, grpc.WithInsecure())
 	}
 
+	cc, err := grpc.Dial(c.Target, dialOps...)
 	if err != nil {
 		return nil, fmt.Errorf("grpc dail fail,target=%v,err=%v", c.ServiceName, err)
 	}
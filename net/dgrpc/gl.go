@@ -49,6 +49,24 @@ func UnaryServerCtxInterceptor() func(ctx context.Context, req interface{}, info
 	}
 }
 
+// serverStream is a grpc.ServerStream of another context, e.g. one with
+// the log values of dlog.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func withStreamContext(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	if s, ok := ss.(*serverStream); ok {
+		return &serverStream{ServerStream: s.ServerStream, ctx: ctx}
+	}
+	return &serverStream{ServerStream: ss, ctx: ctx}
+}
+
 func WithGlInterceptor() InterceptorOption {
 	return func(h *OptionHolder) {
 		h.UnaryClientInterceptors = append(h.UnaryClientInterceptors, UnaryClientCtxInterceptor())
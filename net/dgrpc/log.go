@@ -7,7 +7,6 @@ package dgrpc
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	log "github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/runtime/gl"
@@ -45,38 +44,34 @@ func StreamServerLoggerInterceptor() grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		st := time.Now()
 
-		ip := GetClientIP(stream.Context())
+		ctx := stream.Context()
+		ip := GetClientIP(ctx)
 		if ip != "" {
 			gl.Set(gl.ClientIp, ip)
+			ctx = log.WithClientIp(ctx, ip)
 		}
 
 		gl.Set(gl.Url, info.FullMethod)
-		logData := make(map[string]interface{})
+		ctx = log.WithUrl(ctx, info.FullMethod)
 
-		err := handler(srv, stream)
+		err := handler(srv, withStreamContext(stream, ctx))
 		cost := time.Now().Sub(st)
 		code := status.Code(err)
-		logData["code"] = code.String()
+		entry := log.FromContext(ctx).With("code", code.String())
 		if err != nil {
-			logData["err"] = err.Error()
+			entry = entry.With("err", err.Error())
 		}
 
 		costMs := cost / time.Millisecond
-		logData["cost"] = costMs
+		entry = entry.With("cost", costMs)
 		if costMs >= 50 || err != nil {
-			logData["ctx"] = gl.JsonCurrentCtx()
-		}
-
-		logDataStr, jsonErr := json.Marshal(logData)
-		if jsonErr != nil {
-			log.Warn("logData json marshal fail, error:%s", jsonErr)
-			return err
+			entry = entry.With("ctx", gl.JsonCurrentCtx())
 		}
 
 		if ShouldFail4Code(code) {
-			log.WarnT("SESSION", fmt.Sprintf("%s %s", info.FullMethod, logDataStr))
+			entry.Tag("SESSION").Warn(info.FullMethod)
 		} else {
-			log.WarnT("SESSION", fmt.Sprintf("%s %s", info.FullMethod, logDataStr))
+			entry.Tag("SESSION").Info(info.FullMethod)
 		}
 		return err
 	}
@@ -88,38 +83,32 @@ func UnaryServerLoggerInterceptor() grpc.UnaryServerInterceptor {
 		ip := GetClientIP(context)
 		if ip != "" {
 			gl.Set(gl.ClientIp, ip)
+			context = log.WithClientIp(context, ip)
 		}
 		gl.Set(gl.Url, info.FullMethod)
+		context = log.WithUrl(context, info.FullMethod)
 
-		logData := make(map[string]interface{})
-		logData["args"] = req
+		entry := log.FromContext(context).With("args", req)
 
 		resp, err := handler(context, req)
 
 		cost := time.Now().Sub(st)
 		costMs := cost / time.Millisecond
 		if costMs > 50 || err != nil {
-			ctxJson := gl.JsonCurrentCtx()
-			logData["ctx"] = ctxJson
+			entry = entry.With("ctx", gl.JsonCurrentCtx())
 		}
 
-		logData["ret"] = resp
 		code := status.Code(err)
-		logData["code"] = code.String()
+		entry = entry.With("ret", resp, "code", code.String())
 		if err != nil {
-			logData["err"] = err.Error()
+			entry = entry.With("err", err.Error())
 		}
 
-		logData["cost"] = costMs
-		logDataStr, jsonErr := json.Marshal(logData)
-		if jsonErr != nil {
-			log.Warn("logData json marshal fail, error:%s", jsonErr)
-			return resp, err
-		}
+		entry = entry.With("cost", costMs)
 		if ShouldFail4Code(code) {
-			log.WarnT("SESSION", fmt.Sprintf("%s %s", info.FullMethod, logDataStr))
+			entry.Tag("SESSION").Warn(info.FullMethod)
 		} else {
-			log.InfoT("SESSION", fmt.Sprintf("%s %s", info.FullMethod, logDataStr))
+			entry.Tag("SESSION").Info(info.FullMethod)
 		}
 		return resp, err
 	}
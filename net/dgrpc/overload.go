@@ -0,0 +1,44 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package dgrpc
+
+import (
+	"context"
+	"github.com/Xxianglei/gd/runtime/memwatch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrOverloaded is returned to calls rejected while the process is overloaded.
+var ErrOverloaded = status.Error(codes.Unavailable, "overflow error.")
+
+func UnaryServerOverloadInterceptor() func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if memwatch.Overloaded() {
+			return nil, ErrOverloaded
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerOverloadInterceptor() func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if memwatch.Overloaded() {
+			return ErrOverloaded
+		}
+		return handler(srv, ss)
+	}
+}
+
+// WithOverloadInterceptor rejects calls while memwatch reports the process
+// overloaded.
+func WithOverloadInterceptor() InterceptorOption {
+	return func(h *OptionHolder) {
+		h.UnaryServerInterceptors = append(h.UnaryServerInterceptors, UnaryServerOverloadInterceptor())
+		h.StreamServerInterceptors = append(h.StreamServerInterceptors, StreamServerOverloadInterceptor())
+	}
+}
@@ -8,6 +8,7 @@ package dgrpc
 import (
 	"context"
 	"fmt"
+	"github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/runtime/gl"
 	"github.com/Xxianglei/gd/runtime/pc"
 	"github.com/Xxianglei/gd/utls"
@@ -42,10 +43,10 @@ func ensureTraceId(context context.Context) context.Context {
 	}
 	gl.Set(metaTraceId, traceId)
 	gl.Set(gl.LogId, traceId)
-	return context
+	return dlog.WithLogId(context, traceId)
 }
 
-func ensureTraceIdStream(ss grpc.ServerStream) {
+func ensureTraceIdStream(ss grpc.ServerStream) grpc.ServerStream {
 	traceId := ""
 	ctx := ss.Context()
 	md, ok := metadata.FromIncomingContext(ctx)
@@ -62,6 +63,7 @@ func ensureTraceIdStream(ss grpc.ServerStream) {
 	}
 	gl.Set(metaTraceId, traceId)
 	gl.Set(gl.LogId, traceId)
+	return withStreamContext(ss, dlog.WithLogId(ctx, traceId))
 }
 
 func UnaryClientPerfCounterInterceptor(service string) func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
@@ -105,7 +107,7 @@ func StreamClientPerfCounterInterceptor(service string) func(ctx context.Context
 func StreamServerPerfCounterInterceptor(service string) func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		st := time.Now()
-		ensureTraceIdStream(ss)
+		ss = ensureTraceIdStream(ss)
 		m := info.FullMethod
 		err := handler(srv, ss)
 		cost := time.Now().Sub(st)
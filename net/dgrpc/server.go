@@ -35,11 +35,14 @@ type IRegisterHandler interface {
 type GrpcServer struct {
 	s               *grpc.Server
 	l               net.Listener
+	errChan         chan error
 	startOnce       sync.Once
 	closeOnce       sync.Once
 	GrpcRunPort     int
 	RegisterHandler IRegisterHandler
 	ServiceName     string
+	// Listener is served instead of listening on GrpcRunPort if set
+	Listener net.Listener
 
 	UseTls             bool
 	GrpcCertServerName string // if not, default gd
@@ -62,15 +65,18 @@ func (s *GrpcServer) start() error {
 		return fmt.Errorf("init server fail,err=%v", err)
 	}
 
-	l, err := net.Listen("tcp", fmt.Sprintf(":%d", s.GrpcRunPort))
-	if err != nil {
-		return fmt.Errorf("failed to listen: %v", err)
+	l := s.Listener
+	if l == nil {
+		if l, err = net.Listen("tcp", fmt.Sprintf(":%d", s.GrpcRunPort)); err != nil {
+			return fmt.Errorf("failed to listen: %v", err)
+		}
 	}
 
 	s.s = server
 	s.l = l
 	err = s.RegisterHandler.RegisterHandler(server)
 	if err != nil {
+		l.Close()
 		return err
 	}
 
@@ -80,16 +86,25 @@ func (s *GrpcServer) start() error {
 }
 
 func (s *GrpcServer) startRun() error {
+	s.errChan = make(chan error, 1)
 	go func() {
+		defer close(s.errChan)
 		err := s.s.Serve(s.l)
 		if err != nil {
-			log.Crash("start server fail,addr=%v,err=%v", s.GrpcRunPort, err)
+			log.Error("serve fail,addr=%v,err=%v", s.GrpcRunPort, err)
+			s.errChan <- err
 		}
 	}()
 
 	return nil
 }
 
+// Err returns a channel that receives the error if the server stops serving
+// unexpectedly. It is closed when the server exits.
+func (s *GrpcServer) Err() <-chan error {
+	return s.errChan
+}
+
 func (s *GrpcServer) Register(i IRegisterHandler) {
 	s.RegisterHandler = i
 }
@@ -102,6 +117,7 @@ func (s *GrpcServer) Stop() {
 
 func (s *GrpcServer) DefaultServer() (*grpc.Server, error) {
 	ops := []InterceptorOption{
+		WithOverloadInterceptor(),
 		WithGlInterceptor(),
 		WithPerfCounterInterceptor(s.ServiceName),
 		WithLogInterceptor(),
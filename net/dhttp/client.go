@@ -11,12 +11,17 @@ import (
 	"github.com/parnurzeal/gorequest"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type HttpClient struct {
 	Timeout time.Duration
 	Domain  string
+
+	// timeout of SetTimeout, over Timeout
+	timeout int64
+	onStop  []func()
 }
 
 func (c *HttpClient) Start() error {
@@ -32,8 +37,31 @@ func (c *HttpClient) Start() error {
 	return nil
 }
 
+// SetTimeout changes the timeout of Method, it may be called at any time.
+// OnStop registers fn to be called by Stop, e.g. to cancel a config
+// subscription of the client. It must be called before Stop.
+func (c *HttpClient) OnStop(fn func()) {
+	c.onStop = append(c.onStop, fn)
+}
+
+// Stop releases the client, it calls the functions of OnStop.
+func (c *HttpClient) Stop() {
+	for _, fn := range c.onStop {
+		fn()
+	}
+	c.onStop = nil
+}
+
+func (c *HttpClient) SetTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&c.timeout, int64(timeout))
+}
+
 func (c *HttpClient) Method(method string, path string, header map[string]string, params interface{}) (*http.Response, string, error) {
-	return c.MethodTimeout(method, path, header, params, c.Timeout)
+	timeout := time.Duration(atomic.LoadInt64(&c.timeout))
+	if timeout <= 0 {
+		timeout = c.Timeout
+	}
+	return c.MethodTimeout(method, path, header, params, timeout)
 }
 
 func (c *HttpClient) MethodTimeout(method string, path string, header map[string]string, params interface{}, timeout time.Duration) (*http.Response, string, error) {
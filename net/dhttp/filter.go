@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/runtime/gl"
+	"github.com/Xxianglei/gd/runtime/memwatch"
 	"github.com/Xxianglei/gd/runtime/pc"
 	"github.com/Xxianglei/gd/runtime/stat"
 	"github.com/Xxianglei/gd/utls"
@@ -22,6 +23,18 @@ import (
 	"time"
 )
 
+// OverloadFilter rejects requests with 503 while memwatch reports the process
+// overloaded. It is used by HttpServer by default.
+func OverloadFilter() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if memwatch.Overloaded() {
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+		c.Next()
+	}
+}
+
 // example: group filter
 func GroupFilter() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -63,14 +76,21 @@ func Logger(pk string) gin.HandlerFunc {
 		c.Set(RemoteIP, realIp)
 		gl.Set(gl.ClientIp, realIp)
 
-		c.Next()
-
 		uri := c.Request.RequestURI
 		uriSplits := strings.Split(uri, "?")
 		path := uri
 		if len(uriSplits) > 0 {
 			path = uriSplits[0]
 		}
+		gl.Set(gl.Url, path)
+
+		// the same values for dlog.InfoCtx(c.Request.Context(), ...)
+		ctx := dlog.WithLogId(c.Request.Context(), traceId)
+		ctx = dlog.WithClientIp(ctx, realIp)
+		ctx = dlog.WithUrl(ctx, path)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
 
 		costDu := time.Now().Sub(st)
 		pathPcKey := fmt.Sprintf("%s,uri=path,path=%s", costKey, path)
@@ -112,42 +132,36 @@ func Logger(pk string) gin.HandlerFunc {
 			}
 		}
 
-		message := map[string]interface{}{
-			"httpStatus": httpStatus,
-			"cost":       strconv.FormatInt(int64(cost), 10) + "ms",
-			"err":        errStr,
-		}
+		entry := dlog.FromContext(ctx).With(
+			"httpStatus", httpStatus,
+			"cost", strconv.FormatInt(int64(cost), 10)+"ms",
+			"err", errStr,
+		)
 
 		dataByte, err := json.Marshal(data)
 		if err != nil {
 			dlog.Error("data cant transfer to json ?! data is %v", data)
-			message["data"] = data
+			entry = entry.With("data", data)
 		} else {
 			dataJson, _ := simplejson.NewJson(dataByte)
-			message["data"] = dataJson
+			entry = entry.With("data", dataJson)
 		}
 		retByte, err := json.Marshal(ret)
 		if err != nil {
 			dlog.Error("ret cant transfer to json ?! ret is %v", ret)
-			message["ret"] = ret
+			entry = entry.With("ret", ret)
 		} else {
 			retStr, _ := simplejson.NewJson(retByte)
-			message["ret"] = retStr
+			entry = entry.With("ret", retStr)
 		}
 
-		glData := gl.GetCurrentGlData()
-		message["gl"] = glData
-
-		mj, jsonErr := utls.Marshal(message)
-		if jsonErr != nil {
-			dlog.Error("json marshal occur error:%v", jsonErr)
-		}
+		entry = entry.With("gl", gl.GetCurrentGlData())
 
 		if cost > 50 {
-			dlog.WarnT("SESSION_SLOW", fmt.Sprintf("%s %s", path, string(mj)))
+			entry.Tag("SESSION_SLOW").Warn(path)
 			return
 		}
-		dlog.InfoT("SESSION", fmt.Sprintf("%s %s", path, string(mj)))
+		entry.Tag("SESSION").Info(path)
 	}
 }
 
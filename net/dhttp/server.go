@@ -7,10 +7,12 @@ package dhttp
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/Xxianglei/gd/dlog"
 	"github.com/gin-gonic/gin"
+	"net"
 	"net/http"
 	"time"
 )
@@ -18,8 +20,9 @@ import (
 type HttpServerIniter func(g *gin.Engine) error
 
 type HttpServer struct {
-	server *http.Server
-	g      *gin.Engine
+	server  *http.Server
+	g       *gin.Engine
+	errChan chan error
 
 	NoGinLog                  bool
 	UseHttps                  bool
@@ -30,6 +33,8 @@ type HttpServer struct {
 	HttpServerWriteTimeout    int64
 	HttpServerRunHost         string
 	HttpServerIniter          HttpServerIniter
+	// Listener is served instead of listening on HttpServerRunHost if set
+	Listener net.Listener
 
 	HandlerMap map[string]interface{}
 }
@@ -62,22 +67,45 @@ func (h *HttpServer) Run() error {
 		return err
 	}
 
-	go func() {
-		var err error
-		if h.UseHttps {
-			err = h.server.ListenAndServeTLS(h.HttpsCertFilePath, h.HttpsKeyFilePath)
-		} else {
-			err = h.server.ListenAndServe()
+	// listen here so that address and certificate errors are returned to the caller
+	l := h.Listener
+	if l == nil {
+		if l, err = net.Listen("tcp", h.HttpServerRunHost); err != nil {
+			return fmt.Errorf("http server listen %s fail,%v", h.HttpServerRunHost, err)
+		}
+	}
+
+	if h.UseHttps {
+		cert, err := tls.LoadX509KeyPair(h.HttpsCertFilePath, h.HttpsKeyFilePath)
+		if err != nil {
+			l.Close()
+			return fmt.Errorf("http server load cert fail,%v", err)
 		}
-		if err != nil && err != http.ErrServerClosed {
-			msg := fmt.Sprintf("graceful start http server fail,%v", err)
-			dlog.Crash(msg)
+		h.server.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h2", "http/1.1"},
+		}
+		l = tls.NewListener(l, h.server.TLSConfig)
+	}
+
+	h.errChan = make(chan error, 1)
+	go func() {
+		defer close(h.errChan)
+		if err := h.server.Serve(l); err != nil && err != http.ErrServerClosed {
+			dlog.Error("http server serve %s fail,%v", h.HttpServerRunHost, err)
+			h.errChan <- err
 		}
 	}()
 
 	return nil
 }
 
+// Err returns a channel that receives the error if the server stops serving
+// unexpectedly. It is closed when the server exits.
+func (h *HttpServer) Err() <-chan error {
+	return h.errChan
+}
+
 func (h *HttpServer) Stop() {
 	if h.server == nil {
 		dlog.Info("not graceful http server shutdown %s", h.HttpServerRunHost)
@@ -182,6 +210,7 @@ func (h *HttpServer) initGin() error {
 	} else {
 		g = gin.Default()
 	}
+	g.Use(OverloadFilter())
 
 	err := h.HttpServerIniter(g)
 	if err != nil {
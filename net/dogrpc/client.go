@@ -93,6 +93,7 @@ func (c *Client) Stop() {
 	c.stopLock.Lock()
 	if c.clientStopChan == nil {
 		dlog.Error("the client must be started before stopping it")
+		return
 	}
 	close(c.clientStopChan)
 	c.stopWg.Wait()
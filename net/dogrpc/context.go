@@ -5,10 +5,14 @@
 
 package dogrpc
 
+import "context"
+
 type Context struct {
 	ClientAddr string
 	Seq        uint32
 	Method     string
 	Handler    RpcHandlerFunc
 	Req        []byte
+	// Ctx carries the log values of dlog for the filters, see GlFilter
+	Ctx context.Context
 }
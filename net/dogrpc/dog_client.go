@@ -38,7 +38,7 @@ func (c *RpcClient) DogConnect() (*Client, error) {
 		if cc, ok = c.Cm[addr.String()]; !ok {
 			cc = &Client{
 				Addr:           addr.String(),
-				RequestTimeout: time.Millisecond * time.Duration(c.Timeout),
+				RequestTimeout: c.Timeout,
 				Encoder: func(w io.Writer, bufferSize int) (encoder MessageEncoder, err error) {
 					return &DogPacketEncoder{bw: bufio.NewWriterSize(w, bufferSize)}, nil
 				},
@@ -76,7 +76,7 @@ func (c *RpcClient) DogInvoke(cmd uint32, req []byte, client ...*Client) (code u
 
 	var reqPkt, rspPkt Packet
 	reqPkt = NewDogPacket(cmd, req)
-	if rspPkt, err = ct.CallRetry(reqPkt, c.RetryNum); err != nil {
+	if rspPkt, err = c.call(ct, reqPkt); err != nil {
 		dlog.Error("Invoke CallRetry occur error:%v ", err)
 		return code, nil, err
 	}
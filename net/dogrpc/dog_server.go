@@ -7,6 +7,7 @@ package dogrpc
 
 import (
 	"bufio"
+	"context"
 	"github.com/Xxianglei/gd/dlog"
 	"io"
 	"strconv"
@@ -76,6 +77,7 @@ func (s *RpcServer) dogDispatchPacket(clientAddr string, req Packet) (rsp Packet
 		Method:     strconv.Itoa(int(headCmd)),
 		Handler:    f,
 		Req:        req.(*DogPacket).Body,
+		Ctx:        context.Background(),
 	})
 
 	return NewDogPacketWithRet(packet.Cmd, body, packet.Seq, uint32(code))
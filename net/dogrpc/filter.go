@@ -8,6 +8,7 @@ package dogrpc
 import (
 	"fmt"
 	"github.com/Xxianglei/gd/dlog"
+	"github.com/Xxianglei/gd/runtime/memwatch"
 	"runtime"
 )
 
@@ -34,6 +35,11 @@ func InitFilters(filters []Filter) {
 }
 
 func (f *Filters) Handle(ctx *Context) (uint32, []byte) {
+	// reject before any filter while memory is overloaded
+	if memwatch.Overloaded() {
+		return uint32(OverflowError.Code()), nil
+	}
+
 	if len(f.Filters) == 0 {
 		return handlerWithRecover(ctx.Handler, ctx.Req)
 	}
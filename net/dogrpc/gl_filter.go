@@ -6,6 +6,8 @@
 package dogrpc
 
 import (
+	"context"
+	"github.com/Xxianglei/gd/dlog"
 	"github.com/Xxianglei/gd/runtime/gl"
 	"strconv"
 	"time"
@@ -30,6 +32,14 @@ func (f *GlFilter) Handle(ctx *Context) (code uint32, rsp []byte) {
 	logId := strconv.FormatInt(st.UnixNano(), 10)
 	gl.Set(gl.LogId, logId)
 	gl.Set(gl.ClientIp, ctx.ClientAddr)
+	gl.Set(gl.Url, ctx.Method)
+
+	if ctx.Ctx == nil {
+		ctx.Ctx = context.Background()
+	}
+	ctx.Ctx = dlog.WithLogId(ctx.Ctx, logId)
+	ctx.Ctx = dlog.WithClientIp(ctx.Ctx, ctx.ClientAddr)
+	ctx.Ctx = dlog.WithUrl(ctx.Ctx, ctx.Method)
 
 	if f.next == nil {
 		code, rsp = handlerWithRecover(ctx.Handler, ctx.Req)
@@ -6,7 +6,6 @@
 package dogrpc
 
 import (
-	"encoding/json"
 	de "github.com/Xxianglei/gd/derror"
 	"github.com/Xxianglei/gd/dlog"
 	"time"
@@ -35,27 +34,22 @@ func (f *LogFilter) Handle(ctx *Context) (code uint32, rsp []byte) {
 
 	cost := time.Now().Sub(st)
 
-	logData := make(map[string]interface{})
-	logData["code"] = code
-	logData["ret"] = string(rsp)
-	logData["cost"] = cost / time.Millisecond
-	logData["args"] = string(ctx.Req)
-	logData["seq"] = ctx.Seq
-
-	logDataStr, jsonErr := json.Marshal(logData)
-	if jsonErr != nil {
-		dlog.Warn("logData json marshal fail, error:%s", jsonErr)
-		return uint32(de.SystemError), rsp
-	}
+	log := dlog.FromContext(ctx.Ctx).With(
+		"code", code,
+		"ret", string(rsp),
+		"cost", cost/time.Millisecond,
+		"args", string(ctx.Req),
+		"seq", ctx.Seq,
+	)
 
 	if code != uint32(de.RpcSuccess) {
-		dlog.WarnT("SESSION", "%s %s", ctx.Method, logDataStr)
+		log.Tag("SESSION").Warn(ctx.Method)
 	} else {
-		dlog.InfoT("SESSION", "%s %s", ctx.Method, logDataStr)
+		log.Tag("SESSION").Info(ctx.Method)
 	}
 
 	if f.SlowCostThreshold > 0 && cost > time.Duration(f.SlowCostThreshold)*time.Millisecond {
-		dlog.WarnT("SERVER_SLOW", "%s %s", ctx.Method, logDataStr)
+		log.Tag("SERVER_SLOW").Warn(ctx.Method)
 	}
 
 	return code, rsp
@@ -12,6 +12,7 @@ import (
 	"math/rand"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,6 +27,10 @@ type RpcClient struct {
 	Timeout  time.Duration
 	RetryNum uint32
 	localIp  string
+
+	// request timeout of SetTimeout, over the one of the clients
+	timeout int64
+	onStop  []func()
 }
 
 func NewClient(timeout time.Duration, retryNum uint32) *RpcClient {
@@ -37,6 +42,20 @@ func NewClient(timeout time.Duration, retryNum uint32) *RpcClient {
 	}
 }
 
+// SetTimeout changes the request timeout of Invoke and DogInvoke, it may be
+// called at any time.
+func (c *RpcClient) SetTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&c.timeout, int64(timeout))
+}
+
+// call calls ct with the timeout of SetTimeout, or else the one of ct.
+func (c *RpcClient) call(ct *Client, req Packet) (Packet, *dogError.CodeError) {
+	if timeout := time.Duration(atomic.LoadInt64(&c.timeout)); timeout > 0 {
+		return ct.CallTimeout(req, timeout, c.RetryNum)
+	}
+	return ct.CallRetry(req, c.RetryNum)
+}
+
 // add server address
 func (c *RpcClient) AddAddr(addr string) {
 	if addr2, err := net.ResolveTCPAddr("tcp", addr); err != nil {
@@ -47,11 +66,21 @@ func (c *RpcClient) AddAddr(addr string) {
 }
 
 // Stop stop client
+// OnStop registers fn to be called by Stop, e.g. to cancel a config
+// subscription of the client. It must be called before Stop.
+func (c *RpcClient) OnStop(fn func()) {
+	c.onStop = append(c.onStop, fn)
+}
+
 func (c *RpcClient) Stop() {
 	for addr, cc := range c.Cm {
 		cc.Stop()
 		dlog.Error("dog rpc client stop client %s", addr)
 	}
+	for _, fn := range c.onStop {
+		fn()
+	}
+	c.onStop = nil
 
 	dlog.Info("dog rpc client stop all done.")
 }
@@ -107,7 +136,7 @@ func (c *RpcClient) Invoke(cmd uint32, req []byte, client ...*Client) (code uint
 
 	var reqPkt, rspPkt Packet
 	reqPkt = NewRpcPacket(cmd, req)
-	if rspPkt, err = ct.CallRetry(reqPkt, c.RetryNum); err != nil {
+	if rspPkt, err = c.call(ct, reqPkt); err != nil {
 		dlog.Error("[Invoke] CallRetry occur error:%v ", err)
 		return code, nil, err
 	}
@@ -6,8 +6,10 @@
 package dogrpc
 
 import (
+	"context"
 	"fmt"
 	"github.com/Xxianglei/gd/dlog"
+	"net"
 	"strconv"
 )
 
@@ -52,6 +54,34 @@ func (s *RpcServer) Run(port int) error {
 	return nil
 }
 
+// Start listens on port and serves in background, use Stop to shut it down.
+func (s *RpcServer) Start(port int) error {
+	addr := fmt.Sprintf(":%d", port)
+	dlog.Info("dog rpc try to listen port: %d", port)
+
+	s.addr = addr
+	s.ss.Addr = addr
+
+	err := s.ss.Start()
+	if err != nil {
+		dlog.Error("dog Start occur error:%s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// SetListener makes Start and Run serve on l instead of listening on port.
+func (s *RpcServer) SetListener(l net.Listener) {
+	s.ss.Listener = &defaultListener{L: l}
+}
+
+// Err returns a channel that receives the error if the server stops serving
+// unexpectedly. It is closed when the server exits.
+func (s *RpcServer) Err() <-chan error {
+	return s.ss.Err()
+}
+
 func (s *RpcServer) Stop() {
 	s.ss.Stop()
 }
@@ -95,6 +125,7 @@ func (s *RpcServer) dispatchPacket(clientAddr string, req Packet) (rsp Packet) {
 		Method:     strconv.Itoa(int(headCmd)),
 		Handler:    f,
 		Req:        req.(*RpcPacket).Body,
+		Ctx:        context.Background(),
 	})
 
 	return NewRpcPacketWithRet(packet.Cmd, body, packet.Seq, uint32(code))
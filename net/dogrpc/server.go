@@ -10,6 +10,7 @@ import (
 	dogError "github.com/Xxianglei/gd/derror"
 	"github.com/Xxianglei/gd/dlog"
 	"io"
+	"net"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -28,6 +29,7 @@ type Server struct {
 	RecvBufferSize   int
 	Listener         Listener
 	serverStopChan   chan struct{}
+	errChan          chan error
 	stopWg           sync.WaitGroup
 	Encoder          MessageEncoderFunc
 	Decoder          MessageDecoderFunc
@@ -74,6 +76,7 @@ func (s *Server) Start() *dogError.CodeError {
 		return ce
 	}
 
+	s.errChan = make(chan error, 1)
 	workersCh := make(chan struct{}, s.Concurrency)
 	s.stopWg.Add(1)
 	go serverHandler(s, workersCh)
@@ -88,6 +91,12 @@ func (s *Server) Serve() *dogError.CodeError {
 	return nil
 }
 
+// Err returns a channel that receives the error if the server stops accepting
+// unexpectedly. It is closed when the server exits.
+func (s *Server) Err() <-chan error {
+	return s.errChan
+}
+
 func (s *Server) Stop() {
 	if s.serverStopChan == nil {
 		panic("server must be started before stopping it")
@@ -100,6 +109,7 @@ func (s *Server) Stop() {
 
 func serverHandler(s *Server, workersCh chan struct{}) {
 	defer s.stopWg.Done()
+	defer close(s.errChan)
 
 	var conn io.ReadWriteCloser
 	var clientAddr string
@@ -128,6 +138,11 @@ func serverHandler(s *Server, workersCh chan struct{}) {
 		}
 
 		if err != nil {
+			if ne, ok := err.(net.Error); !ok || !ne.Temporary() {
+				// e.g. the listener is closed, accepting again fails as well
+				s.errChan <- err
+				return
+			}
 			select {
 			case <-s.serverStopChan:
 				return
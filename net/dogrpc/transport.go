@@ -39,7 +39,11 @@ type defaultListener struct {
 	L net.Listener
 }
 
+// Init listens on addr unless L is already set.
 func (ln *defaultListener) Init(addr string) (err error) {
+	if ln.L != nil {
+		return nil
+	}
 	ln.L, err = net.Listen(DefaultDialNetWork, addr)
 	return
 }
@@ -51,16 +55,20 @@ func (ln *defaultListener) ListenAddr() net.Addr {
 	return nil
 }
 
+// Accept returns the next connection. A connection failing to set up
+// keepalive is closed and skipped, so that an error is of the listener.
 func (ln *defaultListener) Accept() (conn io.ReadWriteCloser, clientAddr string, err error) {
-	c, err := ln.L.Accept()
-	if err != nil {
-		return nil, "", err
-	}
-	if err = setupKeepalive(c); err != nil {
-		c.Close()
-		return nil, "", err
+	for {
+		c, err := ln.L.Accept()
+		if err != nil {
+			return nil, "", err
+		}
+		if err = setupKeepalive(c); err != nil {
+			c.Close()
+			continue
+		}
+		return c, c.RemoteAddr().String(), nil
 	}
-	return c, c.RemoteAddr().String(), nil
 }
 
 func (ln *defaultListener) Close() error {
@@ -0,0 +1,239 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+// Package inherit passes listeners from a process to the new process started
+// by Upgrade, so that a binary can be replaced without refusing connections.
+package inherit
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EnvListeners holds the inherited listeners as "name=fd,name=fd"
+	EnvListeners = "GD_INHERIT_LISTENERS"
+	// EnvReadyFd holds the fd that the new process writes to when it is ready
+	EnvReadyFd = "GD_INHERIT_READY_FD"
+)
+
+var (
+	lock      sync.Mutex
+	parsed    bool
+	inherited map[string]net.Listener
+	active    = make(map[string]net.Listener)
+	child     = os.Getenv(EnvReadyFd) != ""
+)
+
+// Listen returns the listener named name inherited from the parent process,
+// or listens on addr if there is none. The listener is passed on by Upgrade.
+func Listen(name, addr string) (net.Listener, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := parse(); err != nil {
+		return nil, err
+	}
+
+	l, ok := inherited[name]
+	if ok {
+		delete(inherited, name)
+	} else {
+		var err error
+		if l, err = net.Listen("tcp", addr); err != nil {
+			return nil, err
+		}
+	}
+
+	active[name] = l
+	return l, nil
+}
+
+// Inherited reports whether the process was started by Upgrade.
+func Inherited() bool {
+	return child
+}
+
+// Ready tells the parent process that this process serves, the parent stops
+// after that. Inherited listeners not taken by Listen are closed.
+func Ready() error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for name, l := range inherited {
+		l.Close()
+		delete(inherited, name)
+	}
+
+	v := os.Getenv(EnvReadyFd)
+	if v == "" {
+		return nil
+	}
+	os.Unsetenv(EnvReadyFd)
+	os.Unsetenv(EnvListeners)
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", EnvReadyFd, v)
+	}
+
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+	_, err = f.Write([]byte{1})
+	return err
+}
+
+// Upgrade starts the executable of the process again with the same arguments
+// and passes all listeners of Listen to it. It returns when the new process
+// calls Ready, or kills the new process if it exits or timeout passes first.
+func Upgrade(timeout time.Duration) error {
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	files, env, err := listenerFiles()
+	if err != nil {
+		w.Close()
+		return err
+	}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	// fd 0-2 are stdin, stdout and stderr, ExtraFiles start at 3
+	readyFd := 3 + len(files)
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, w)
+	cmd.Env = append(environ(),
+		fmt.Sprintf("%s=%s", EnvListeners, env),
+		fmt.Sprintf("%s=%d", EnvReadyFd, readyFd))
+
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return err
+	}
+
+	// read returns EOF without a byte if the new process exits before ready
+	ready := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1)
+		_, err := r.Read(b)
+		ready <- err
+	}()
+
+	select {
+	case err = <-ready:
+		if err == nil {
+			return nil
+		}
+		err = fmt.Errorf("new process %d exit before ready", cmd.Process.Pid)
+	case <-time.After(timeout):
+		err = fmt.Errorf("new process %d not ready after %v", cmd.Process.Pid, timeout)
+	}
+
+	cmd.Process.Kill()
+	cmd.Wait()
+	return err
+}
+
+func listenerFiles() ([]*os.File, string, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	names := make([]string, 0, len(active))
+	for name := range active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var files []*os.File
+	var env []string
+	for _, name := range names {
+		tl, ok := active[name].(*net.TCPListener)
+		if !ok {
+			continue
+		}
+		f, err := tl.File()
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, "", fmt.Errorf("listener %s,%v", name, err)
+		}
+		env = append(env, fmt.Sprintf("%s=%d", name, 3+len(files)))
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, "", errors.New("no listener to pass")
+	}
+	return files, strings.Join(env, ","), nil
+}
+
+// parse restores the listeners of EnvListeners once.
+func parse() error {
+	if parsed {
+		return nil
+	}
+	parsed = true
+	inherited = make(map[string]net.Listener)
+
+	v := os.Getenv(EnvListeners)
+	if v == "" {
+		return nil
+	}
+
+	for _, kv := range strings.Split(v, ",") {
+		i := strings.IndexByte(kv, '=')
+		if i <= 0 {
+			return fmt.Errorf("invalid %s %q", EnvListeners, v)
+		}
+		fd, err := strconv.Atoi(kv[i+1:])
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", EnvListeners, v)
+		}
+
+		f := os.NewFile(uintptr(fd), kv[:i])
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("inherit listener %s fail,%v", kv[:i], err)
+		}
+		inherited[kv[:i]] = l
+	}
+	return nil
+}
+
+// environ returns the environment without the variables of this package.
+func environ() []string {
+	var ret []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, EnvListeners+"=") || strings.HasPrefix(kv, EnvReadyFd+"=") {
+			continue
+		}
+		ret = append(ret, kv)
+	}
+	return ret
+}
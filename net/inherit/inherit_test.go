@@ -0,0 +1,78 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package inherit
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"testing"
+)
+
+func reset() {
+	parsed = false
+	inherited = nil
+	active = make(map[string]net.Listener)
+}
+
+func TestListenInherited(t *testing.T) {
+	reset()
+	defer reset()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(EnvListeners, fmt.Sprintf("http=%d", f.Fd()))
+	defer os.Unsetenv(EnvListeners)
+
+	hl, err := Listen("http", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hl.Close()
+	if hl.Addr().String() != l.Addr().String() {
+		t.Errorf("addr = %s, want inherited %s", hl.Addr(), l.Addr())
+	}
+
+	gl, err := Listen("grpc", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gl.Close()
+	if gl.Addr().String() == l.Addr().String() {
+		t.Error("grpc should listen on a new address")
+	}
+
+	files, env, err := listenerFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		f.Close()
+	}
+	if env != "grpc=3,http=4" {
+		t.Errorf("env = %q", env)
+	}
+}
+
+func TestListenInvalidEnv(t *testing.T) {
+	reset()
+	defer reset()
+
+	os.Setenv(EnvListeners, "http")
+	defer os.Unsetenv(EnvListeners)
+
+	if _, err := Listen("http", "127.0.0.1:0"); err == nil {
+		t.Error("invalid env should fail")
+	}
+}
@@ -0,0 +1,153 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"github.com/Xxianglei/gd/config"
+	"github.com/Xxianglei/gd/dlog"
+	"github.com/Xxianglei/gd/net/inherit"
+	"net"
+)
+
+type Option func(*Engine)
+
+// WithConfig makes the Engine read c instead of the package config. Config
+// reload on SIGHUP only applies to the package config.
+func WithConfig(c *config.Conf) Option {
+	return func(e *Engine) {
+		e.conf = c
+	}
+}
+
+// WithLogger makes the Engine log to l instead of the package logger.
+func WithLogger(l dlog.Logger) Option {
+	return func(e *Engine) {
+		e.log = l
+	}
+}
+
+// WithSignal enables or disables signal handling, default enabled. Without it
+// the Engine stops on Stop or when the context of RunContext is done.
+func WithSignal(enable bool) Option {
+	return func(e *Engine) {
+		e.signal = enable
+	}
+}
+
+// WithHttpPort overrides [Server] httpPort, 0 disables the http server.
+func WithHttpPort(port int) Option {
+	return withPort(ComponentHttp, port)
+}
+
+// WithGrpcPort overrides [Server] grpcPort, 0 disables the grpc server.
+func WithGrpcPort(port int) Option {
+	return withPort(ComponentGrpc, port)
+}
+
+// WithRpcPort overrides [Server] rpcPort, 0 disables the rpc server.
+func WithRpcPort(port int) Option {
+	return withPort(ComponentRpc, port)
+}
+
+// WithHealthPort overrides [Process] healthPort, 0 disables the health server.
+func WithHealthPort(port int) Option {
+	return withPort(ComponentHealth, port)
+}
+
+// WithProbePort overrides [Process] probePort, 0 disables the probe server.
+func WithProbePort(port int) Option {
+	return withPort(ComponentProbe, port)
+}
+
+func withPort(name string, port int) Option {
+	return func(e *Engine) {
+		e.ports[name] = port
+	}
+}
+
+// WithListener makes the server named name (ComponentHttp, ComponentGrpc,
+// ComponentRpc, ComponentHealth or ComponentProbe) serve on l. The server runs
+// on the port of l unless a port is configured.
+func WithListener(name string, l net.Listener) Option {
+	return func(e *Engine) {
+		e.listeners[name] = l
+	}
+}
+
+// Config returns a key of the config of the Engine.
+func (e *Engine) Config(name, key string) *config.Key {
+	if e.conf != nil {
+		return e.conf.Key(name, key)
+	}
+	return Config(name, key)
+}
+
+// BindConfig sets the struct v points to from a section of the config of the
+// Engine, see config.Bind.
+func (e *Engine) BindConfig(section string, v interface{}) error {
+	if e.conf != nil {
+		return e.conf.Bind(section, v)
+	}
+	return config.Bind(section, v)
+}
+
+// ValidateConfig checks the config of the Engine against the declared keys,
+// see config.Validate.
+func (e *Engine) ValidateConfig() error {
+	if e.conf != nil {
+		return e.conf.Validate()
+	}
+	return config.Validate()
+}
+
+// port returns the port of the server named name, see withPort.
+func (e *Engine) port(name, section, key string) int {
+	if port, ok := e.ports[name]; ok {
+		return port
+	}
+	if port := e.Config(section, key).MustInt(); port > 0 {
+		return port
+	}
+	if l, ok := e.listeners[name]; ok {
+		if addr, ok := l.Addr().(*net.TCPAddr); ok {
+			return addr.Port
+		}
+	}
+	return 0
+}
+
+// listen returns the listener of WithListener, or a listener inherited from
+// the process before upgrade, or listens on addr.
+func (e *Engine) listen(name, addr string) (net.Listener, error) {
+	if l, ok := e.listeners[name]; ok {
+		return l, nil
+	}
+	return inherit.Listen(name, addr)
+}
+
+func (e *Engine) info(arg0 interface{}, args ...interface{}) {
+	if e.log != nil {
+		e.log.Info(arg0, args...)
+		return
+	}
+	Info(arg0, args...)
+}
+
+func (e *Engine) warn(arg0 interface{}, args ...interface{}) {
+	if e.log != nil {
+		e.log.Warn(arg0, args...)
+		return
+	}
+	Warn(arg0, args...)
+}
+
+func (e *Engine) error(arg0 interface{}, args ...interface{}) {
+	if e.log != nil {
+		e.log.Error(arg0, args...)
+		return
+	}
+	Error(arg0, args...)
+}
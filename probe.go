@@ -0,0 +1,82 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Xxianglei/gd/runtime/health"
+	"net"
+	"time"
+)
+
+const defaultProbeTimeout = 1000
+
+type checkerEntry struct {
+	name    string
+	checker health.Checker
+}
+
+// AddChecker adds a readiness check served by /readyz of the probe server,
+// see [Process] probePort. It must be called before Run.
+func (e *Engine) AddChecker(name string, checker health.Checker) {
+	e.checkers = append(e.checkers, checkerEntry{name: name, checker: checker})
+}
+
+// Readiness runs the readiness checks of every component and every checker added
+// by AddChecker. A component is checked by its Check method if it is a
+// health.Checker, or else by Health.
+func (e *Engine) Readiness(ctx context.Context) *health.Report {
+	return e.readiness().Run(ctx, e.probeTimeout())
+}
+
+func (e *Engine) readiness() *health.Checks {
+	checks := &health.Checks{}
+	for _, c := range e.components {
+		checks.Add(c.name, componentChecker{c})
+	}
+	for _, c := range e.checkers {
+		checks.Add(c.name, c.checker)
+	}
+	return checks
+}
+
+func (e *Engine) probeTimeout() time.Duration {
+	return time.Duration(e.Config("Process", "probeTimeoutMs").MustInt(defaultProbeTimeout)) * time.Millisecond
+}
+
+type componentChecker struct {
+	c *componentEntry
+}
+
+func (c componentChecker) Check(ctx context.Context) error {
+	if !c.c.isStarted() {
+		return fmt.Errorf("component %s not started", c.c.name)
+	}
+	if checker, ok := c.c.Component.(health.Checker); ok {
+		return checker.Check(ctx)
+	}
+	return c.c.Health()
+}
+
+// checkListener connects to a tcp listener, other listeners such as in-memory
+// ones of tests are not checked.
+func checkListener(ctx context.Context, l net.Listener) error {
+	if l == nil {
+		return errors.New("not listening")
+	}
+	addr := l.Addr()
+	if addr.Network() != "tcp" {
+		return nil
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr.String())
+	if err != nil {
+		return fmt.Errorf("connect %s fail,%v", addr, err)
+	}
+	return conn.Close()
+}
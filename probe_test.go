@@ -0,0 +1,102 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Xxianglei/gd/runtime/health"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func getReport(t *testing.T, url string) (int, *health.Report) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	rsp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+
+	report := &health.Report{}
+	if err := json.NewDecoder(rsp.Body).Decode(report); err != nil {
+		t.Fatal(err)
+	}
+	return rsp.StatusCode, report
+}
+
+func TestEngineProbe(t *testing.T) {
+	e, _ := newTestEngine(t, "pong")
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithListener(ComponentProbe, l)(e)
+
+	var down int32
+	e.AddChecker("dep", health.CheckFunc(func(ctx context.Context) error {
+		if atomic.LoadInt32(&down) == 1 {
+			return errors.New("dep down")
+		}
+		return nil
+	}))
+
+	errs := make(chan error, 1)
+	go func() { errs <- e.Run() }()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := e.Stop(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	select {
+	case <-e.Ready():
+		if err := e.StartError(); err != nil {
+			t.Fatal(err)
+		}
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("engine not ready")
+	}
+
+	if name := e.components[0].name; name != ComponentProbe {
+		t.Errorf("first component = %s, want %s", name, ComponentProbe)
+	}
+
+	base := "http://" + l.Addr().String()
+	if code, _ := getReport(t, base+"/healthz"); code != http.StatusOK {
+		t.Errorf("healthz code = %d", code)
+	}
+
+	code, report := getReport(t, base+"/readyz")
+	if code != http.StatusOK {
+		t.Errorf("readyz code = %d, report = %+v", code, report)
+	}
+	names := make(map[string]bool)
+	for _, r := range report.Checks {
+		names[r.Name] = true
+	}
+	for _, name := range []string{ComponentProbe, ComponentHttp, ComponentRegister, "dep"} {
+		if !names[name] {
+			t.Errorf("check %s missing in %+v", name, report.Checks)
+		}
+	}
+
+	atomic.StoreInt32(&down, 1)
+	if code, report := getReport(t, base+"/readyz"); code != http.StatusServiceUnavailable || report.Ok() {
+		t.Errorf("readyz code = %d, report = %+v, want fail", code, report)
+	}
+}
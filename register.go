@@ -0,0 +1,104 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Xxianglei/gd/server/register"
+	"github.com/Xxianglei/gd/utls/network"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDrainTime = 5
+	defaultWeight    = 10
+)
+
+type registerPort struct {
+	name string
+	port int
+}
+
+// startRegister registers every started listener from the [Register] section.
+// The service node of a listener is named "<serverName>_<http|rpc|grpc>".
+func (e *Engine) startRegister(ports []registerPort) error {
+	if !e.Config("Register", "enable").MustBool(false) {
+		return nil
+	}
+
+	hosts := e.Config("Register", "zkHost").Strings(",")
+	if len(hosts) == 0 {
+		return errors.New("register enabled but zkHost not set")
+	}
+
+	root := "/" + strings.Trim(e.Config("Register", "root").MustString("root"), "/")
+	group := e.Config("Register", "group").String()
+	env := e.Config("Register", "env").String()
+	weight := e.Config("Register", "weight").MustUint64(defaultWeight)
+	ip := e.Config("Register", "ip").MustString(network.GetLocalIP())
+	serverName := e.Config("Server", "serverName").String()
+
+	for _, p := range ports {
+		if p.port <= 0 {
+			continue
+		}
+
+		r := e.newRegister()
+		service := fmt.Sprintf("%s_%s", serverName, p.name)
+		r.NewRegister(hosts, root, env, group, service)
+		if err := r.Run(ip, p.port, weight); err != nil {
+			r.Close()
+			e.closeRegister()
+			return fmt.Errorf("register %s %s:%d fail,%v", service, ip, p.port, err)
+		}
+
+		e.registers = append(e.registers, r)
+		e.info("register %s %s:%d ok", service, ip, p.port)
+	}
+	return nil
+}
+
+// stopRegister sets all nodes offline, waits [Register] drainTime seconds for
+// clients to move away and then removes the nodes.
+func (e *Engine) stopRegister() {
+	if len(e.registers) == 0 {
+		return
+	}
+
+	// the new process of an upgrade has taken over the nodes
+	if e.upgraded {
+		e.closeRegister()
+		return
+	}
+
+	for _, r := range e.registers {
+		r.SetOffline(true)
+	}
+
+	drainTime := e.Config("Register", "drainTime").MustInt(defaultDrainTime)
+	if drainTime > 0 {
+		e.info("register set offline, wait %ds to drain", drainTime)
+		time.Sleep(time.Duration(drainTime) * time.Second)
+	}
+
+	e.closeRegister()
+}
+
+func (e *Engine) closeRegister() {
+	for _, r := range e.registers {
+		r.Close()
+	}
+	e.registers = nil
+}
+
+func (e *Engine) newRegister() register.DogRegister {
+	if e.NewRegister != nil {
+		return e.NewRegister()
+	}
+	return &register.ZkRegister{}
+}
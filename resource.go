@@ -0,0 +1,124 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"fmt"
+	"github.com/Xxianglei/gd/runtime/cgroup"
+	"github.com/Xxianglei/gd/utls"
+	"runtime"
+)
+
+const (
+	// part of the cgroup memory limit used as memory budget, the rest is
+	// left for the memory not managed by go
+	memoryBudgetPercent = 90
+	// part of the memory budget used as memwatch soft limit
+	memorySoftPercent = 80
+)
+
+// resourceLimits are the CPU and memory settings chosen at start and where
+// they come from: "config", "default" or the cgroup version.
+type resourceLimits struct {
+	maxCPU       int
+	cpuSource    string
+	memory       int64
+	memorySource string
+}
+
+func (r resourceLimits) String() string {
+	return fmt.Sprintf("maxCPU=%d(%s),memoryBudget=%d(%s)", r.maxCPU, r.cpuSource, r.memory, r.memorySource)
+}
+
+// MemoryBudget returns the memory the process should stay below in bytes,
+// from [Process] maxMemory or the cgroup memory limit. 0 means no budget.
+func (e *Engine) MemoryBudget() int64 {
+	return e.resource.memory
+}
+
+// initCPUAndMemory sets GOMAXPROCS and the memory budget, which is the memory
+// limit of the runtime too. [Process] maxCPU and maxMemory take precedence
+// over the limits of the cgroup found at [Process] cgroupRoot.
+func (e *Engine) initCPUAndMemory() error {
+	limits, err := cgroup.Read(e.Config("Process", "cgroupRoot").MustString(cgroup.DefaultRoot))
+	if err != nil {
+		e.error("Cannot read cgroup limits, ignore cgroup, error = %s", err.Error())
+		limits = cgroup.Limits{}
+	}
+	e.info("cgroup limits: %s", limits)
+	source := fmt.Sprintf("cgroup v%d", limits.Version)
+
+	r := resourceLimits{cpuSource: "config", memorySource: "config"}
+	r.maxCPU = e.Config("Process", "maxCPU").MustInt()
+	numCpus := runtime.NumCPU()
+	if r.maxCPU <= 0 {
+		if limits.CPU > 0 {
+			// round down to avoid being throttled, but use at least one
+			r.maxCPU = int(limits.CPU)
+			if r.maxCPU < 1 {
+				r.maxCPU = 1
+			}
+			r.cpuSource = source
+		} else if numCpus > 3 {
+			r.maxCPU = numCpus / 2
+			r.cpuSource = "default"
+		} else {
+			r.maxCPU = 1
+			r.cpuSource = "default"
+		}
+	}
+	if r.maxCPU > numCpus {
+		r.maxCPU = numCpus
+	}
+	runtime.GOMAXPROCS(r.maxCPU)
+
+	// the memory watchdog keeps the heap below the budget, RLIMIT_AS would
+	// crash the process when reached
+	if e.Config("Process", "maxMemory").String() != "" {
+		maxMemory, err := utls.ParseMemorySize(e.Config("Process", "maxMemory").String())
+		if err != nil {
+			return fmt.Errorf("conf field illgeal, maxMemory:%s, error:%s", e.Config("Process", "maxMemory").String(), err.Error())
+		}
+		r.memory = int64(maxMemory)
+	} else if limits.Memory > 0 {
+		r.memory = limits.Memory / 100 * memoryBudgetPercent
+		r.memorySource = source
+	} else {
+		r.memorySource = "default"
+	}
+	if r.memory > 0 {
+		setMemoryLimit(r.memory)
+	}
+
+	e.resource = r
+	e.info("resource limits: %s", r)
+	return nil
+}
+
+// memwatchLimits returns the soft and hard heap limits from [Process]
+// memorySoftLimit and memoryHardLimit, derived from the memory budget if not
+// set.
+func (e *Engine) memwatchLimits() (soft, hard uint64, err error) {
+	if v := e.Config("Process", "memorySoftLimit").String(); v != "" {
+		if soft, err = utls.ParseMemorySize(v); err != nil {
+			return 0, 0, fmt.Errorf("conf field illgeal, memorySoftLimit:%s, error:%s", v, err.Error())
+		}
+	}
+	if v := e.Config("Process", "memoryHardLimit").String(); v != "" {
+		if hard, err = utls.ParseMemorySize(v); err != nil {
+			return 0, 0, fmt.Errorf("conf field illgeal, memoryHardLimit:%s, error:%s", v, err.Error())
+		}
+	}
+
+	budget := uint64(e.MemoryBudget())
+	if hard == 0 {
+		hard = budget
+	}
+	if soft == 0 && budget > 0 {
+		soft = budget / 100 * memorySoftPercent
+	}
+	return soft, hard, nil
+}
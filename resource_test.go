@@ -0,0 +1,70 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"github.com/Xxianglei/gd/config"
+	"gopkg.in/ini.v1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestInitCPUAndMemoryCgroup(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	defer func(f func(int64)) { setMemoryLimit = f }(setMemoryLimit)
+	var memoryLimit int64
+	setMemoryLimit = func(limit int64) { memoryLimit = limit }
+
+	root, err := ioutil.TempDir("", "cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for name, content := range map[string]string{
+		"cgroup.controllers": "cpu memory",
+		"cpu.max":            "100000 100000",
+		"memory.max":         "1000",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := ini.Empty()
+	f.Section("Process").Key("cgroupRoot").SetValue(root)
+	e := New(WithConfig(config.NewConf(f)))
+	if err := e.initCPUAndMemory(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := resourceLimits{maxCPU: 1, cpuSource: "cgroup v2", memory: 900, memorySource: "cgroup v2"}
+	if e.resource != want {
+		t.Errorf("resource = %v, want %v", e.resource, want)
+	}
+	if runtime.GOMAXPROCS(0) != 1 {
+		t.Errorf("GOMAXPROCS = %d, want 1", runtime.GOMAXPROCS(0))
+	}
+	if memoryLimit != 900 {
+		t.Errorf("memory limit = %d, want 900", memoryLimit)
+	}
+
+	// config takes precedence
+	f.Section("Process").Key("maxCPU").SetValue("1")
+	if err := e.initCPUAndMemory(); err != nil {
+		t.Fatal(err)
+	}
+	if e.resource.cpuSource != "config" {
+		t.Errorf("cpu source = %s, want config", e.resource.cpuSource)
+	}
+
+	f.Section("Process").Key("maxMemory").SetValue("2x")
+	if err := e.initCPUAndMemory(); err == nil {
+		t.Error("invalid maxMemory should fail")
+	}
+}
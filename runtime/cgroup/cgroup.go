@@ -0,0 +1,136 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+// Package cgroup reads the CPU and memory limits of the cgroup the process
+// runs in, both cgroup v1 and v2 are supported.
+package cgroup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const DefaultRoot = "/sys/fs/cgroup"
+
+// cgroup v1 reports no memory limit as a huge page aligned number
+const unlimitedMemory = 1 << 62
+
+type Limits struct {
+	// Version is 1 or 2, 0 if no cgroup is found
+	Version int
+	// CPU is the number of CPUs of the quota, 0 means no limit
+	CPU float64
+	// Memory is the memory limit in bytes, 0 means no limit
+	Memory int64
+}
+
+func (l Limits) String() string {
+	if l.Version == 0 {
+		return "no cgroup"
+	}
+	return fmt.Sprintf("cgroup v%d cpu=%g memory=%d", l.Version, l.CPU, l.Memory)
+}
+
+// Read reads the limits from the cgroup filesystem mounted at root.
+func Read(root string) (Limits, error) {
+	if root == "" {
+		root = DefaultRoot
+	}
+
+	if exists(filepath.Join(root, "cgroup.controllers")) {
+		return readV2(root)
+	}
+	if exists(filepath.Join(root, "cpu")) || exists(filepath.Join(root, "memory")) {
+		return readV1(root)
+	}
+	return Limits{}, nil
+}
+
+func readV2(root string) (Limits, error) {
+	l := Limits{Version: 2}
+
+	// cpu.max is "$MAX $PERIOD", $MAX is "max" if not limited
+	if s, err := readString(filepath.Join(root, "cpu.max")); err != nil {
+		return l, err
+	} else if fields := strings.Fields(s); len(fields) == 2 && fields[0] != "max" {
+		quota, err1 := strconv.ParseFloat(fields[0], 64)
+		period, err2 := strconv.ParseFloat(fields[1], 64)
+		if err1 != nil || err2 != nil || period <= 0 {
+			return l, fmt.Errorf("invalid cpu.max %q", s)
+		}
+		l.CPU = quota / period
+	}
+
+	if s, err := readString(filepath.Join(root, "memory.max")); err != nil {
+		return l, err
+	} else if s != "" && s != "max" {
+		mem, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return l, fmt.Errorf("invalid memory.max %q", s)
+		}
+		l.Memory = mem
+	}
+	return l, nil
+}
+
+func readV1(root string) (Limits, error) {
+	l := Limits{Version: 1}
+
+	// cfs_quota_us is -1 if not limited
+	quota, err := readInt(filepath.Join(root, "cpu", "cpu.cfs_quota_us"))
+	if err != nil {
+		return l, err
+	}
+	period, err := readInt(filepath.Join(root, "cpu", "cpu.cfs_period_us"))
+	if err != nil {
+		return l, err
+	}
+	if quota > 0 && period > 0 {
+		l.CPU = float64(quota) / float64(period)
+	}
+
+	mem, err := readInt(filepath.Join(root, "memory", "memory.limit_in_bytes"))
+	if err != nil {
+		return l, err
+	}
+	if mem > 0 && mem < unlimitedMemory {
+		l.Memory = mem
+	}
+	return l, nil
+}
+
+// readString returns the trimmed content of a file, "" if it does not exist.
+func readString(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// readInt returns the number in a file, -1 if it does not exist.
+func readInt(path string) (int64, error) {
+	s, err := readString(path)
+	if err != nil || s == "" {
+		return -1, err
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("invalid %s %q", filepath.Base(path), s)
+	}
+	return n, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,85 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package cgroup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	root, err := ioutil.TempDir("", "cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  Limits
+	}{
+		{"none", map[string]string{}, Limits{}},
+		{"v2", map[string]string{
+			"cgroup.controllers": "cpu memory",
+			"cpu.max":            "150000 100000\n",
+			"memory.max":         "536870912\n",
+		}, Limits{Version: 2, CPU: 1.5, Memory: 536870912}},
+		{"v2 unlimited", map[string]string{
+			"cgroup.controllers": "cpu memory",
+			"cpu.max":            "max 100000\n",
+			"memory.max":         "max\n",
+		}, Limits{Version: 2}},
+		{"v1", map[string]string{
+			"cpu/cpu.cfs_quota_us":         "200000\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+			"memory/memory.limit_in_bytes": "1073741824\n",
+		}, Limits{Version: 1, CPU: 2, Memory: 1073741824}},
+		{"v1 unlimited", map[string]string{
+			"cpu/cpu.cfs_quota_us":         "-1\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+			"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		}, Limits{Version: 1}},
+	}
+
+	for _, tt := range tests {
+		root := writeFiles(t, tt.files)
+		got, err := Read(root)
+		os.RemoveAll(root)
+		if err != nil {
+			t.Errorf("%s: err = %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: limits = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"cgroup.controllers": "cpu memory",
+		"cpu.max":            "abc 100000\n",
+	})
+	defer os.RemoveAll(root)
+
+	if _, err := Read(root); err == nil {
+		t.Error("invalid cpu.max should fail")
+	}
+}
@@ -46,6 +46,24 @@ func (s *StopableTaskGroup) Close() {
 	s.WaitStopAll()
 }
 
+// Name, Init, Health and Stop make StopableTaskGroup a gd.Component
+func (s *StopableTaskGroup) Name() string {
+	return "stopable_task_group"
+}
+
+func (s *StopableTaskGroup) Init() error {
+	return nil
+}
+
+func (s *StopableTaskGroup) Health() error {
+	return nil
+}
+
+func (s *StopableTaskGroup) Stop() error {
+	s.WaitStopAll()
+	return nil
+}
+
 func (s *StopableTaskGroup) WaitStopAll() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -0,0 +1,174 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+// Package health runs named checks with a timeout and serves the results as
+// liveness and readiness endpoints.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout = time.Second
+
+	StatusOk   = "ok"
+	StatusFail = "fail"
+)
+
+// Checker returns nil if the checked dependency works. Check should return
+// when ctx is done.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+type CheckFunc func(ctx context.Context) error
+
+func (f CheckFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Ok reports whether all checks pass.
+func (r *Report) Ok() bool {
+	return r.Status == StatusOk
+}
+
+type namedChecker struct {
+	name string
+	Checker
+}
+
+// Checks is a list of named checkers.
+type Checks struct {
+	lock     sync.Mutex
+	checkers []namedChecker
+}
+
+// Add adds a checker, a checker with the same name is replaced.
+func (c *Checks) Add(name string, checker Checker) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i := range c.checkers {
+		if c.checkers[i].name == name {
+			c.checkers[i].Checker = checker
+			return
+		}
+	}
+	c.checkers = append(c.checkers, namedChecker{name: name, Checker: checker})
+}
+
+// Run runs all checks concurrently, each one at most timeout. A check that
+// does not return in time is reported as failed and left running.
+func (c *Checks) Run(ctx context.Context, timeout time.Duration) *Report {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	c.lock.Lock()
+	checkers := make([]namedChecker, len(c.checkers))
+	copy(checkers, c.checkers)
+	c.lock.Unlock()
+
+	report := &Report{
+		Status: StatusOk,
+		Checks: make([]Result, len(checkers)),
+	}
+
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func(i int, checker namedChecker) {
+			defer wg.Done()
+			report.Checks[i] = run(ctx, checker, timeout)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	for _, r := range report.Checks {
+		if r.Status != StatusOk {
+			report.Status = StatusFail
+			break
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, checker namedChecker, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	st := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if x := recover(); x != nil {
+				done <- fmt.Errorf("check panic,%v", x)
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timeout after %v", timeout)
+	}
+
+	r := Result{
+		Name:      checker.name,
+		Status:    StatusOk,
+		LatencyMs: float64(time.Since(st).Microseconds()) / 1000,
+	}
+	if err != nil {
+		r.Status = StatusFail
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// Handler serves /healthz, which is ok while the process serves http, and
+// /readyz, which runs the checks returned by ready and responds 503 if any
+// of them fails.
+func Handler(ready func() *Checks, timeout time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, &Report{Status: StatusOk, Checks: []Result{}})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		report := ready().Run(r.Context(), timeout)
+		code := http.StatusOK
+		if !report.Ok() {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	b, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
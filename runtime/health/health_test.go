@@ -0,0 +1,73 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChecksRun(t *testing.T) {
+	var c Checks
+	c.Add("ok", CheckFunc(func(ctx context.Context) error { return nil }))
+	c.Add("fail", CheckFunc(func(ctx context.Context) error { return errors.New("down") }))
+	c.Add("slow", CheckFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+	c.Add("panic", CheckFunc(func(ctx context.Context) error { panic("boom") }))
+
+	report := c.Run(context.Background(), 20*time.Millisecond)
+	if report.Ok() {
+		t.Fatal("report should fail")
+	}
+	want := []string{StatusOk, StatusFail, StatusFail, StatusFail}
+	for i, r := range report.Checks {
+		if r.Status != want[i] {
+			t.Errorf("%s: status = %s, want %s", r.Name, r.Status, want[i])
+		}
+	}
+	if report.Checks[2].LatencyMs > 500 {
+		t.Errorf("slow check latency = %v, should stop at timeout", report.Checks[2].LatencyMs)
+	}
+
+	c.Add("fail", CheckFunc(func(ctx context.Context) error { return nil }))
+	c.Add("slow", CheckFunc(func(ctx context.Context) error { return nil }))
+	c.Add("panic", CheckFunc(func(ctx context.Context) error { return nil }))
+	if report := c.Run(context.Background(), time.Second); !report.Ok() || len(report.Checks) != 4 {
+		t.Errorf("report = %+v, want 4 ok checks", report)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var c Checks
+	c.Add("db", CheckFunc(func(ctx context.Context) error { return errors.New("down") }))
+	h := Handler(func() *Checks { return &c }, time.Second)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("healthz code = %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz code = %d", w.Code)
+	}
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Checks) != 1 || report.Checks[0].Name != "db" || report.Checks[0].Error != "down" {
+		t.Errorf("report = %+v", report)
+	}
+}
@@ -34,6 +34,7 @@ type Helper struct {
 	listener net.Listener
 
 	Host     string
+	Listener net.Listener // used instead of listening on Host if set
 	Updater  UpdateFunc
 	Finder   FindFunc
 	Perfer   PerfFunc
@@ -42,13 +43,16 @@ type Helper struct {
 }
 
 func (helper *Helper) Start() error {
-	l, err := net.Listen("tcp", helper.Host)
-	if err != nil {
-		return fmt.Errorf("host=%s,%v", helper.Host, err)
+	l := helper.Listener
+	if l == nil {
+		var err error
+		if l, err = net.Listen("tcp", helper.Host); err != nil {
+			return fmt.Errorf("host=%s,%v", helper.Host, err)
+		}
 	}
 	helper.listener = l
 
-	err = os.MkdirAll(ProfDumpDir, 0755)
+	err := os.MkdirAll(ProfDumpDir, 0755)
 	if err != nil {
 		dlog.Info("Helper create dir fail, path:%s, err:%v", ProfDumpDir, err)
 	}
@@ -219,6 +223,7 @@ func (helper *Helper) help(client net.Conn) {
 	client.Write([]byte("  trace n \t\tdump n second trace info to prof/trace_xxx.out\n"))
 	client.Write([]byte("  heap <nogc>\t\tdump current heap to prof/heap_xxx.prof\n"))
 	client.Write([]byte("  dlog\t\t-1:CURRENT,0:FNST,1:FINE,2:DEBG,3:TRAC,4:INFO,5:WARN,6:EROR,7:CRIT\n"))
+	client.Write([]byte("  log tag|pkg\t<tag|package> <level|reset> <ttl>\tlevel of a tag or package, e.g. log tag SESSION 2 10m\n"))
 	client.Write([]byte("  deploy\t<file1> <file2> ;\"deploy all\" means deploy all accessable file\n"))
 }
 
@@ -254,6 +259,15 @@ func (helper *Helper) dealCommand(client net.Conn) {
 			key := arr[2]
 			value := strings.Join(arr[3:], " ")
 			ret = helper.Updater(id+" "+key, value, tpe, -1)
+		} else if tpe == "log" && len(arr) >= 4 && (arr[1] == "tag" || arr[1] == "pkg") {
+			ttl := ""
+			if len(arr) > 4 {
+				ttl = arr[4]
+			}
+			if err := setLevel(arr[1], arr[2], arr[3], ttl); err != nil {
+				client.Write([]byte("<" + err.Error() + "\n"))
+				ret = false
+			}
 		} else if tpe == "log" {
 			if len(arr) != 2 {
 				helper.help(client)
@@ -265,7 +279,7 @@ func (helper *Helper) dealCommand(client net.Conn) {
 				client.Write([]byte("<" + err.Error() + "\n"))
 				ret = false
 			} else if lvl == -1 {
-				client.Write([]byte("<log:" + dlog.GetLevel() + "\n"))
+				client.Write([]byte("<log:" + dlog.GetLevel() + "\n" + levelsString()))
 			} else {
 				dlog.Debug("SetLogLevel:%d", lvl)
 				dlog.SetLevel(lvl)
@@ -0,0 +1,154 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package helper
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/Xxianglei/gd/dlog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LevelPath is the path of the levels of tags and packages served by
+// LevelHandler.
+const LevelPath = "/admin/log/levels"
+
+// parseLevel parses a level of a number, e.g. 2, or a name, e.g. DEBUG or
+// WARN.
+func parseLevel(s string) (dlog.Level, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < int(dlog.FINEST) || n > int(dlog.CRITICAL) {
+			return 0, fmt.Errorf("invalid level %d", n)
+		}
+		return dlog.Level(n), nil
+	}
+	s = strings.ToUpper(s)
+	if lvl, ok := dlog.ParseLevel(s); ok {
+		return lvl, nil
+	}
+	for lvl := dlog.FINEST; lvl <= dlog.CRITICAL; lvl++ {
+		if lvl.String() == s {
+			return lvl, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid level %q", s)
+}
+
+// setLevel sets or, of level "reset", removes the level of a tag or a
+// package, by kind "tag" or "pkg".
+func setLevel(kind, key, level, ttl string) error {
+	if key == "" {
+		return fmt.Errorf("empty %s", kind)
+	}
+	if level == "reset" {
+		var ok bool
+		if kind == "tag" {
+			ok = dlog.ResetTagLevel(key)
+		} else {
+			ok = dlog.ResetPackageLevel(key)
+		}
+		if !ok {
+			return fmt.Errorf("no level of %s %s", kind, key)
+		}
+		dlog.Global.LogWithTag("LOG_LEVEL", dlog.INFO, "helper", fmt.Sprintf("level of %s %s reset", kind, key))
+		return nil
+	}
+
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	var d time.Duration
+	if ttl != "" {
+		if d, err = time.ParseDuration(ttl); err != nil {
+			return err
+		}
+	}
+	if kind == "tag" {
+		dlog.SetTagLevel(key, lvl, d)
+	} else {
+		dlog.SetPackageLevel(key, lvl, d)
+	}
+	dlog.Global.LogWithTag("LOG_LEVEL", dlog.INFO, "helper", fmt.Sprintf("level of %s %s set to %s, ttl %s", kind, key, lvl, d))
+	return nil
+}
+
+// levelsString returns the levels of the tags and packages, a line each.
+func levelsString() string {
+	var b strings.Builder
+	for _, o := range dlog.LevelOverrides() {
+		if o.Tag != "" {
+			fmt.Fprintf(&b, "tag %s %s", o.Tag, o.Level)
+		} else {
+			fmt.Fprintf(&b, "pkg %s %s", o.Package, o.Level)
+		}
+		if !o.Expire.IsZero() {
+			fmt.Fprintf(&b, " expire %s", o.Expire.Format(time.RFC3339))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+type levelJSON struct {
+	Tag     string `json:"tag,omitempty"`
+	Package string `json:"package,omitempty"`
+	Level   string `json:"level"`
+	Expire  string `json:"expire,omitempty"`
+}
+
+// LevelHandler serves the levels of tags and packages at LevelPath, for
+// requests of the bearer token:
+//
+//	GET                                  the levels as json
+//	POST ?tag=SESSION&level=DEBUG&ttl=10m sets the level of a tag, or package=
+//	DELETE ?tag=SESSION                  removes the level of a tag, or package=
+//
+// An empty token serves nothing.
+func LevelHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		kind, key := "tag", r.FormValue("tag")
+		if key == "" {
+			kind, key = "pkg", r.FormValue("package")
+		}
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			err = setLevel(kind, key, r.FormValue("level"), r.FormValue("ttl"))
+		case http.MethodDelete:
+			err = setLevel(kind, key, "reset", "")
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		levels := []levelJSON{}
+		for _, o := range dlog.LevelOverrides() {
+			l := levelJSON{Tag: o.Tag, Package: o.Package, Level: o.Level.String()}
+			if !o.Expire.IsZero() {
+				l.Expire = o.Expire.Format(time.RFC3339)
+			}
+			levels = append(levels, l)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levels)
+	})
+}
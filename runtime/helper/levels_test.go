@@ -0,0 +1,61 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package helper
+
+import (
+	"encoding/json"
+	"github.com/Xxianglei/gd/dlog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	defer dlog.ResetLevels()
+	srv := httptest.NewServer(LevelHandler("secret"))
+	defer srv.Close()
+
+	do := func(method, query, token string) (int, []levelJSON) {
+		req, _ := http.NewRequest(method, srv.URL+LevelPath+query, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rsp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rsp.Body.Close()
+		var levels []levelJSON
+		json.NewDecoder(rsp.Body).Decode(&levels)
+		return rsp.StatusCode, levels
+	}
+
+	if code, _ := do("GET", "", ""); code != http.StatusUnauthorized {
+		t.Errorf("no token code = %d", code)
+	}
+	if code, _ := do("GET", "", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("wrong token code = %d", code)
+	}
+	if code, _ := do("POST", "?tag=SESSION&level=LOUD", "secret"); code != http.StatusBadRequest {
+		t.Errorf("invalid level code = %d", code)
+	}
+
+	code, levels := do("POST", "?tag=SESSION&level=DEBUG&ttl=10m", "secret")
+	if code != http.StatusOK || len(levels) != 1 || levels[0].Tag != "SESSION" || levels[0].Level != "DEBUG" || levels[0].Expire == "" {
+		t.Errorf("set code = %d, levels = %+v", code, levels)
+	}
+	code, levels = do("POST", "?package=github.com/Xxianglei/gd/net&level=5", "secret")
+	if code != http.StatusOK || len(levels) != 2 || levels[1].Package != "github.com/Xxianglei/gd/net" || levels[1].Level != "WARN" {
+		t.Errorf("set code = %d, levels = %+v", code, levels)
+	}
+
+	if code, levels = do("DELETE", "?tag=SESSION", "secret"); code != http.StatusOK || len(levels) != 1 {
+		t.Errorf("delete code = %d, levels = %+v", code, levels)
+	}
+	if code, _ = do("DELETE", "?tag=SESSION", "secret"); code != http.StatusBadRequest {
+		t.Errorf("delete again code = %d", code)
+	}
+}
@@ -0,0 +1,177 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+// Package memwatch samples the heap and marks the process overloaded when it
+// grows above a hard limit. The servers of gd reject new requests while the
+// process is overloaded.
+package memwatch
+
+import (
+	"errors"
+	"github.com/Xxianglei/gd/dlog"
+	"github.com/Xxianglei/gd/runtime/helper"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultInterval     = time.Second
+	DefaultGCInterval   = 10 * time.Second
+	DefaultDumpInterval = 10 * time.Minute
+)
+
+var overloaded int32
+
+// Overloaded reports whether new requests should be rejected.
+func Overloaded() bool {
+	return atomic.LoadInt32(&overloaded) == 1
+}
+
+// SetOverloaded sets the overloaded flag and reports whether it changed.
+func SetOverloaded(v bool) bool {
+	if v {
+		return atomic.CompareAndSwapInt32(&overloaded, 0, 1)
+	}
+	return atomic.CompareAndSwapInt32(&overloaded, 1, 0)
+}
+
+// Watchdog forces a GC and writes a heap profile when the heap is above Soft,
+// and sets the process overloaded when it is still above Hard after the GC.
+// The process accepts requests again when the heap is below Soft. Between
+// two GCs the process stays as it is while the heap is above Soft.
+type Watchdog struct {
+	// Soft and Hard are heap sizes in bytes, 0 disables the limit
+	Soft uint64
+	Hard uint64
+	// Interval between samples, default DefaultInterval
+	Interval time.Duration
+	// GCInterval is the minimum time between forced GCs, default
+	// DefaultGCInterval
+	GCInterval time.Duration
+	// DumpInterval is the minimum time between heap profiles, default
+	// DefaultDumpInterval
+	DumpInterval time.Duration
+
+	// for tests, default runtime.ReadMemStats, runtime.GC and helper.WriteHeap
+	ReadMemStats func(m *runtime.MemStats)
+	GC           func()
+	WriteHeap    func()
+
+	lastGC   time.Time
+	lastDump time.Time
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// Start samples the heap in background until Stop.
+func (w *Watchdog) Start() error {
+	if w.Soft == 0 && w.Hard == 0 {
+		return errors.New("memwatch soft and hard limit not set")
+	}
+	if w.Soft > 0 && w.Hard > 0 && w.Soft > w.Hard {
+		return errors.New("memwatch soft limit above hard limit")
+	}
+	if w.Soft == 0 {
+		w.Soft = w.Hard
+	}
+	if w.Interval <= 0 {
+		w.Interval = DefaultInterval
+	}
+	if w.GCInterval <= 0 {
+		w.GCInterval = DefaultGCInterval
+	}
+	if w.DumpInterval <= 0 {
+		w.DumpInterval = DefaultDumpInterval
+	}
+	if w.ReadMemStats == nil {
+		w.ReadMemStats = runtime.ReadMemStats
+	}
+	if w.GC == nil {
+		w.GC = runtime.GC
+	}
+	if w.WriteHeap == nil {
+		w.WriteHeap = helper.WriteHeap
+	}
+
+	w.stop = make(chan struct{})
+	w.wg.Add(1)
+	go w.run()
+	dlog.Info("memwatch start, soft=%d, hard=%d, interval=%v", w.Soft, w.Hard, w.Interval)
+	return nil
+}
+
+func (w *Watchdog) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	w.wg.Wait()
+	w.stop = nil
+
+	if SetOverloaded(false) {
+		dlog.Info("memwatch stop, accept requests again")
+	}
+}
+
+func (w *Watchdog) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+func (w *Watchdog) check() {
+	heap := w.heap()
+	if heap < w.Soft {
+		w.recover(heap)
+		return
+	}
+
+	if time.Since(w.lastGC) < w.GCInterval {
+		return
+	}
+	w.lastGC = time.Now()
+	w.GC()
+	heap = w.heap()
+	if heap < w.Soft {
+		w.recover(heap)
+		return
+	}
+
+	if w.Hard > 0 && heap >= w.Hard {
+		if SetOverloaded(true) {
+			dlog.Error("memwatch heap %d above hard limit %d after gc, reject requests", heap, w.Hard)
+		}
+	} else {
+		dlog.Warn("memwatch heap %d above soft limit %d after gc", heap, w.Soft)
+	}
+
+	if time.Since(w.lastDump) >= w.DumpInterval {
+		w.lastDump = time.Now()
+		w.WriteHeap()
+	}
+}
+
+func (w *Watchdog) recover(heap uint64) {
+	if SetOverloaded(false) {
+		dlog.Info("memwatch heap %d below soft limit %d, accept requests again", heap, w.Soft)
+	}
+}
+
+func (w *Watchdog) heap() uint64 {
+	var m runtime.MemStats
+	w.ReadMemStats(&m)
+	return m.HeapAlloc
+}
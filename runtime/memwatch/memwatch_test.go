@@ -0,0 +1,100 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package memwatch
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWatchdogCheck(t *testing.T) {
+	defer SetOverloaded(false)
+
+	var heap uint64
+	var gcs, dumps int
+	w := &Watchdog{
+		Soft:         100,
+		Hard:         200,
+		DumpInterval: time.Hour,
+		ReadMemStats: func(m *runtime.MemStats) { m.HeapAlloc = heap },
+		GC:           func() { gcs++ },
+		WriteHeap:    func() { dumps++ },
+	}
+
+	steps := []struct {
+		heap       uint64
+		overloaded bool
+		gcs        int
+		dumps      int
+	}{
+		{50, false, 0, 0},
+		{150, false, 1, 1},
+		{250, true, 2, 1}, // dump is rate limited
+		{150, true, 3, 1}, // stay overloaded until below soft
+		{50, false, 3, 1},
+	}
+	for i, s := range steps {
+		heap = s.heap
+		w.check()
+		if Overloaded() != s.overloaded || gcs != s.gcs || dumps != s.dumps {
+			t.Errorf("step %d: overloaded = %v, gcs = %d, dumps = %d, want %v, %d, %d",
+				i, Overloaded(), gcs, dumps, s.overloaded, s.gcs, s.dumps)
+		}
+	}
+}
+
+func TestWatchdogGCInterval(t *testing.T) {
+	defer SetOverloaded(false)
+
+	var heap uint64
+	var gcs int
+	w := &Watchdog{
+		Soft:         100,
+		Hard:         200,
+		GCInterval:   time.Hour,
+		DumpInterval: time.Hour,
+		ReadMemStats: func(m *runtime.MemStats) { m.HeapAlloc = heap },
+		GC:           func() { gcs++ },
+		WriteHeap:    func() {},
+	}
+
+	steps := []struct {
+		heap       uint64
+		overloaded bool
+		gcs        int
+	}{
+		{150, false, 1},
+		{250, false, 1}, // gc is rate limited, stay as is
+		{50, false, 1},
+	}
+	for i, s := range steps {
+		heap = s.heap
+		w.check()
+		if Overloaded() != s.overloaded || gcs != s.gcs {
+			t.Errorf("step %d: overloaded = %v, gcs = %d, want %v, %d", i, Overloaded(), gcs, s.overloaded, s.gcs)
+		}
+	}
+}
+
+func TestWatchdogStart(t *testing.T) {
+	if err := (&Watchdog{}).Start(); err == nil {
+		t.Error("start without limit should fail")
+	}
+	if err := (&Watchdog{Soft: 2, Hard: 1}).Start(); err == nil {
+		t.Error("soft above hard should fail")
+	}
+
+	w := &Watchdog{Hard: 1 << 62, Interval: time.Millisecond}
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	w.Stop()
+	if Overloaded() {
+		t.Error("should not be overloaded")
+	}
+}
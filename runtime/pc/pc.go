@@ -67,6 +67,8 @@ type costTimer struct {
 func init() {
 	cMap.SHARD_COUNT = defaultShardCount
 	kMap = cMap.New()
+	// counts of the logs suppressed and dropped by dlog
+	dlog.SetCounter(Incr)
 }
 
 func Init() {
@@ -274,6 +276,12 @@ func report() {
 	goNums := int64(runtime.NumGoroutine())
 	kMap.Set(GoProjectsGoroutineNum, &goNums)
 
+	// the buffered records of the log writers, the drops are counted by Incr
+	for k, v := range dlog.QueueDepths() {
+		depth := v
+		kMap.Set(k, &depth)
+	}
+
 	suffixDeciderLock.RLock()
 	sufDecider := suffixDecider
 	suffixDeciderLock.RUnlock()
@@ -126,6 +126,7 @@ type StatMgr struct {
 	c         chan *Stat
 	statFile  *os.File
 	statGap   time.Duration
+	gapChan   chan time.Duration
 	maxCmdLen int
 }
 
@@ -135,8 +136,9 @@ var once sync.Once
 func StatMgrInstance() *StatMgr {
 	once.Do(func() {
 		statMgr = &StatMgr{
-			m: make(map[string]*StatValue),
-			c: make(chan *Stat, 4096),
+			m:       make(map[string]*StatValue),
+			c:       make(chan *Stat, 4096),
+			gapChan: make(chan time.Duration, 1),
 		}
 	})
 	return statMgr
@@ -156,6 +158,11 @@ func (mgr *StatMgr) Init(statPath string, statGap time.Duration) {
 			select {
 			case <-ticker.C:
 				mgr.dump()
+			case gap := <-mgr.gapChan:
+				ticker.Stop()
+				ticker = time.NewTicker(gap)
+				mgr.statGap = gap
+				dlog.Info("stat interval changed to %v", gap)
 			case st := <-mgr.c:
 				mgr.stat(st)
 			}
@@ -163,6 +170,18 @@ func (mgr *StatMgr) Init(statPath string, statGap time.Duration) {
 	}()
 }
 
+// SetStatGap changes the dump interval of a running StatMgr.
+func (mgr *StatMgr) SetStatGap(statGap time.Duration) {
+	if statGap <= 0 {
+		return
+	}
+	select {
+	case mgr.gapChan <- statGap:
+	default:
+		dlog.Warn("stat interval change to %v is pending, ignore", statGap)
+	}
+}
+
 func (mgr *StatMgr) addStat(st *Stat) {
 	mgr.c <- st
 }
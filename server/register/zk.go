@@ -6,6 +6,7 @@
 package register
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -24,6 +25,7 @@ type ZkRegister struct {
 	nodeInfo server.NodeInfo // service node info
 	client   *zk.Conn        // zk client
 	environ  string          //service run environment
+	path     string          // registered node path
 }
 
 func (z *ZkRegister) NewRegister(hosts []string, root, environ, group, service string) {
@@ -45,7 +47,21 @@ func (z *ZkRegister) NewRegister(hosts []string, root, environ, group, service s
 }
 
 func (z *ZkRegister) SetOffline(offline bool) {
+	if z.nodeInfo == nil {
+		return
+	}
 	z.nodeInfo.(*server.DefaultNodeInfo).Offline = offline
+
+	// update the registered node, so that discovery sees the new state
+	if z.client == nil || z.path == "" {
+		return
+	}
+	dataByte, _ := json.Marshal(&z.nodeInfo)
+	if _, err := z.client.Set(z.path, dataByte, -1); err != nil {
+		dlog.Error("zk set offline=%v path:%s occur error:%s", offline, z.path, err)
+		return
+	}
+	dlog.Info("zk set offline=%v success! path:%s", offline, z.path)
 }
 
 func (z *ZkRegister) SetRootNode(root string) (err error) {
@@ -99,6 +115,7 @@ func (z *ZkRegister) Run(ip string, port int, weight uint64) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			dlog.Error("zk register panic %s", r)
+			err = fmt.Errorf("zk register panic %v", r)
 			return
 		}
 	}()
@@ -132,6 +149,13 @@ func (z *ZkRegister) run() (err error) {
 
 	dataByte, _ := json.Marshal(&z.nodeInfo)
 	path, err := z.client.Create(p, dataByte, zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
+	if err == zk.ErrNodeExists {
+		// take over the node of a previous session, e.g. the process before upgrade
+		dlog.Warn("zk node %s exists, replace it", p)
+		if err = z.client.Delete(p, -1); err == nil || err == zk.ErrNoNode {
+			path, err = z.client.Create(p, dataByte, zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
+		}
+	}
 	if err != nil {
 		dlog.Error("zk create occur error:%s", err)
 		return
@@ -140,13 +164,37 @@ func (z *ZkRegister) run() (err error) {
 	if path == p {
 		dlog.Info("zk create success! path:%s", path)
 	}
+	z.path = path
 
 	return
 }
 
+// Check returns an error if the zk session is lost or the node is not
+// registered, it is a health.Checker
+func (z *ZkRegister) Check(ctx context.Context) error {
+	if z.client == nil {
+		return errors.New("zk not connected")
+	}
+	if state := z.client.State(); state != zk.StateHasSession {
+		return fmt.Errorf("zk state %s", state)
+	}
+	if z.path == "" {
+		return errors.New("zk node not registered")
+	}
+	isExist, _, err := z.client.Exists(z.path)
+	if err != nil {
+		return fmt.Errorf("zk exists %s fail,%v", z.path, err)
+	}
+	if !isExist {
+		return fmt.Errorf("zk node %s not exist", z.path)
+	}
+	return nil
+}
+
 func (z *ZkRegister) Close() {
 	if z.client != nil {
 		z.client.Close()
 		z.client = nil
 	}
+	z.path = ""
 }
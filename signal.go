@@ -11,30 +11,38 @@ import (
 	"syscall"
 )
 
+// signal channels of the Engine created by Default, an Engine created by New
+// has its own
 var (
-	Shutdown = make(chan os.Signal)
-	Running  = make(chan bool)
-	Hup      = make(chan os.Signal)
+	Shutdown = make(chan os.Signal, 1)
+	// Running stops the running Engine of Default when it receives a value
+	Running = make(chan bool)
+	Hup     = make(chan os.Signal, 1)
+	// Upgrade receives SIGUSR2, Run then passes the listeners to a new process
+	Upgrade = make(chan os.Signal, 1)
 )
 
-func init() {
-	signal.Notify(Shutdown, syscall.SIGINT, os.Interrupt, os.Kill, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGUSR2)
-	signal.Notify(Hup, syscall.SIGHUP)
+// Signal registers the signals handled by Run.
+func (e *Engine) Signal() {
+	signal.Notify(e.shutdown, syscall.SIGINT, os.Interrupt, os.Kill, syscall.SIGTERM, syscall.SIGQUIT)
+	signal.Notify(e.hup, syscall.SIGHUP)
+	signal.Notify(e.upgradeSig, syscall.SIGUSR2)
+	e.info("register signal ok")
 }
 
-func (e *Engine) Signal() {
-	go func() {
-		for {
-			select {
-			case sig := <-Shutdown:
-				Info("receive signal: %v, to stop server...", sig)
-				if e.RpcServer.GetAddr() != "" {
-					e.RpcServer.Stop()
-				}
-				Running <- false
-			case <-Hup:
-			}
-		}
-	}()
-	Info("register signal ok")
+func (e *Engine) stopSignal() {
+	signal.Stop(e.shutdown)
+	signal.Stop(e.hup)
+	signal.Stop(e.upgradeSig)
+}
+
+// reloadConfig reloads the package config on SIGHUP.
+func (e *Engine) reloadConfig() {
+	if e.conf != nil {
+		e.info("config of engine is not the package config, ignore reload")
+		return
+	}
+	if err := ReloadConfig(); err != nil {
+		e.error("reload config fail, keep previous config, error = %s", err.Error())
+	}
 }
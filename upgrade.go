@@ -0,0 +1,21 @@
+/**
+ * Copyright 2020 gd Author. All rights reserved.
+ * Author: Xxianglei
+ */
+
+package gd
+
+import (
+	"github.com/Xxianglei/gd/net/inherit"
+	"time"
+)
+
+const defaultUpgradeTimeout = 30
+
+// upgrade starts the binary again with the listeners of the servers and waits
+// [Process] upgradeTimeout seconds for it to be ready, the result is sent to
+// done. On success the new process serves and this one should stop.
+func (e *Engine) upgrade(done chan<- error) {
+	timeout := e.Config("Process", "upgradeTimeout").MustInt(defaultUpgradeTimeout)
+	done <- inherit.Upgrade(time.Duration(timeout) * time.Second)
+}